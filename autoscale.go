@@ -0,0 +1,246 @@
+/* *****************************************************************************
+Copyright (c) 2023, sameeroak1110 (sameeroak1110@gmail.com)
+BSD 3-Clause License.
+
+Package     : github.com/sameeroak1110/gowp
+Filename    : github.com/sameeroak1110/gowp/autoscale.go
+File-type   : GoLang source code file
+
+Compiler/Runtime: go version go1.20.5 linux/amd64
+
+Version History
+Version     : 1.0
+Author      : Sameer Oak (sameeroak1110@gmail.com)
+
+Description :
+- Autoscaler. While the worker-pool is in action, the no. of workers is kept in between the
+configured minimum and maximum as per a scaling policy.
+- The policy is evaluated periodically with the queue length, queue wait time, job run time,
+and the idle period of the worker-pool. Cooldowns limit how often the worker-pool is scaled.
+***************************************************************************** */
+package gowp
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const defaultScaleInterval time.Duration = time.Second
+const defaultScaleUpWait time.Duration = 100 * time.Millisecond
+const defaultScaleDownIdle time.Duration = 30 * time.Second
+
+// Autoscaler configuration, see WorkerPoolOptions.Autoscale.
+type AutoscaleOptions struct {
+	MinWorkers        int32         // minimum no. of workers, the worker-pool starts with these many. at least 1.
+	MaxWorkers        int32         // maximum no. of workers. at least MinWorkers.
+	Interval          time.Duration // how often the policy is evaluated. default is 1 second.
+	ScaleUpCooldown   time.Duration // minimum time in between a scaling and the next scaling up.
+	ScaleDownCooldown time.Duration // minimum time in between a scaling and the next scaling down.
+	Policy            ScalePolicy   // optional, default is QueueScalePolicy with its defaults.
+}
+
+// Metrics a ScalePolicy decides upon. Durations are as observed since the last evaluation.
+type ScaleMetrics struct {
+	Workers    int32          // current no. of workers.
+	Busy       int32          // no. of workers executing a job, as in Stats().
+	Idle       int32          // no. of workers not executing a job, as in Stats().
+	QueueLen   int            // no. of queued jobs.
+	QueueWait  time.Duration  // average time the jobs waited in the queue, or the age of the oldest queued job if it's larger.
+	RunTime    time.Duration  // average time the jobs took to finish.
+	IdleFor    time.Duration  // how long the queue has been empty with at least one worker idle.
+	MinWorkers int32
+	MaxWorkers int32
+}
+
+// - ScalePolicy returns the desired no. of workers for the metrics.
+// - The autoscaler limits the result to [MinWorkers, MaxWorkers] and applies the cooldowns.
+type ScalePolicy interface {
+	Scale(ScaleMetrics) int32
+}
+
+// ScalePolicyFunc adapts a function to ScalePolicy.
+type ScalePolicyFunc func(ScaleMetrics) int32
+
+// - QueueScalePolicy scales up if jobs wait in the queue for longer than QueueWait, or if there
+// are more than QueueLenPerWorker queued jobs per worker. The worker-pool then grows by half its
+// size, or by as many workers as the queued jobs if that's fewer.
+// - It scales down once the queue has been empty, and some workers idle, for IdleFor. Half of the
+// idle workers are retired then.
+type QueueScalePolicy struct {
+	QueueWait         time.Duration // default is 100 milliseconds.
+	QueueLenPerWorker int           // 0 means queue length isn't considered.
+	IdleFor           time.Duration // default is 30 seconds.
+}
+
+
+func (f ScalePolicyFunc) Scale(m ScaleMetrics) int32 {
+	return f(m)
+}
+
+
+func (p QueueScalePolicy) Scale(m ScaleMetrics) int32 {
+	queueWait := p.QueueWait
+	if queueWait <= 0 {
+		queueWait = defaultScaleUpWait
+	}
+
+	idleFor := p.IdleFor
+	if idleFor <= 0 {
+		idleFor = defaultScaleDownIdle
+	}
+
+	if (m.QueueLen > 0) && ((m.QueueWait > queueWait) ||
+		((p.QueueLenPerWorker > 0) && (m.QueueLen > p.QueueLenPerWorker * int(m.Workers)))) {
+		step := (m.Workers + 1) / 2
+		if int(step) > m.QueueLen {
+			step = int32(m.QueueLen)
+		}
+		return m.Workers + step
+	}
+
+	if (m.IdleFor >= idleFor) && (m.Idle > 0) {
+		step := (m.Idle + 1) / 2
+		return m.Workers - step
+	}
+
+	return m.Workers
+}
+
+
+// validates the autoscaler configuration.
+func (opts *AutoscaleOptions) validate() error {
+	if opts.MinWorkers < 1 {
+		return fmt.Errorf("%w: autoscale minimum workers %d, must be at least 1", ErrInvalidSize, opts.MinWorkers)
+	}
+
+	if opts.MaxWorkers < opts.MinWorkers {
+		return fmt.Errorf("%w: autoscale maximum workers %d, must be at least minimum workers %d", ErrInvalidSize,
+			opts.MaxWorkers, opts.MinWorkers)
+	}
+
+	return nil
+}
+
+
+// age of the oldest queued job, 0 if the queue is empty.
+func (pwp *WorkerPool) oldestQueued() time.Duration {
+	pwp.qmu.Lock()
+	defer pwp.qmu.Unlock()
+
+	j, ok := pwp.jobq.peek()
+	if !ok {
+		return 0
+	}
+
+	return time.Since(j.submittedAt)
+}
+
+
+// collects the metrics observed since the previous invocation. prev holds the latency totals of
+// the worker-pool statistics as of the previous invocation, it's updated to the current ones.
+func (pwp *WorkerPool) scaleMetrics(idleSince time.Time, prev *latencyTotals) ScaleMetrics {
+	m := ScaleMetrics {
+		MinWorkers: pwp.autoscale.MinWorkers,
+		MaxWorkers: pwp.autoscale.MaxWorkers,
+	}
+	m.Workers, m.Busy, m.Idle = pwp.workerUsage()
+
+	pwp.qmu.Lock()
+	m.QueueLen = pwp.jobq.len()
+	pwp.qmu.Unlock()
+
+	lt := pwp.stats.latencyTotals()
+	if cnt := lt.qwaitN - prev.qwaitN; cnt > 0 {
+		m.QueueWait = (lt.qwait - prev.qwait) / time.Duration(cnt)
+	}
+	if oldest := pwp.oldestQueued(); oldest > m.QueueWait {
+		m.QueueWait = oldest
+	}
+
+	if cnt := lt.runtimeN - prev.runtimeN; cnt > 0 {
+		m.RunTime = (lt.runtime - prev.runtime) / time.Duration(cnt)
+	}
+	*prev = lt
+
+	if !idleSince.IsZero() {
+		m.IdleFor = time.Since(idleSince)
+	}
+
+	return m
+}
+
+
+/* *****************************************************************************
+Description : Runs the autoscaler. Invoked as a go-routine by Start() if autoscaling is enabled.
+
+Receiver    : *WorkerPool
+
+Implements  : NA
+
+Arguments   :
+1> ctx context.Context: Context passed to Start().
+2> stopped <-chan struct{}: Closed once the current run of Start() returns.
+
+Return value: NA
+
+Additional note: NA
+***************************************************************************** */
+func (pwp *WorkerPool) runAutoscaler(ctx context.Context, stopped <-chan struct{}) {
+	as := pwp.autoscale
+	interval := as.Interval
+	if interval <= 0 {
+		interval = defaultScaleInterval
+	}
+
+	policy := as.Policy
+	if policy == nil {
+		policy = QueueScalePolicy{}
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var idleSince time.Time   // since when the queue is empty with some workers idle.
+	lastScaled := time.Now()
+	totals := pwp.stats.latencyTotals()  // latencies of the previous runs aren't considered.
+	for {
+		select {
+			case <-ctx.Done():
+				return
+
+			case <-pwp.GetContext().Done():
+				return
+
+			case <-stopped:
+				return
+
+			case <-ticker.C:
+		}
+
+		m := pwp.scaleMetrics(idleSince, &totals)
+		if (m.QueueLen == 0) && (m.Idle > 0) {
+			if idleSince.IsZero() {
+				idleSince = time.Now()
+			}
+		} else {
+			idleSince = time.Time{}
+		}
+
+		n := policy.Scale(m)
+		if n < as.MinWorkers {
+			n = as.MinWorkers
+		}
+		if n > as.MaxWorkers {
+			n = as.MaxWorkers
+		}
+
+		since := time.Since(lastScaled)
+		if ((n > m.Workers) && (since >= as.ScaleUpCooldown)) || ((n < m.Workers) && (since >= as.ScaleDownCooldown)) {
+			if err := pwp.Resize(n); err == nil {
+				lastScaled = time.Now()
+				idleSince = time.Time{}  // idle period starts afresh at the new size.
+			}
+		}
+	}
+}
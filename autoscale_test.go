@@ -0,0 +1,85 @@
+package gowp
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestQueueScalePolicy(t *testing.T) {
+	tests := []struct {
+		name string
+		policy QueueScalePolicy
+		m ScaleMetrics
+		want int32
+	}{
+		{"steady", QueueScalePolicy{}, ScaleMetrics{Workers: 4, Busy: 2, Idle: 2}, 4},
+		{"long queue wait", QueueScalePolicy{}, ScaleMetrics{Workers: 4, Busy: 4, QueueLen: 10, QueueWait: time.Second}, 6},
+		{"step limited by queue", QueueScalePolicy{}, ScaleMetrics{Workers: 8, Busy: 8, QueueLen: 1, QueueWait: time.Second}, 9},
+		{"queue length per worker", QueueScalePolicy{QueueLenPerWorker: 2}, ScaleMetrics{Workers: 2, Busy: 2, QueueLen: 5}, 3},
+		{"short queue", QueueScalePolicy{QueueLenPerWorker: 2}, ScaleMetrics{Workers: 2, Busy: 2, QueueLen: 4}, 2},
+		{"idle", QueueScalePolicy{IdleFor: time.Second}, ScaleMetrics{Workers: 5, Busy: 1, Idle: 4, IdleFor: time.Second}, 3},
+		{"idle not long enough", QueueScalePolicy{IdleFor: time.Second}, ScaleMetrics{Workers: 5, Idle: 5, IdleFor: time.Millisecond}, 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if n := tt.policy.Scale(tt.m); n != tt.want {
+				t.Fatalf("Scale(%+v) = %d, want %d", tt.m, n, tt.want)
+			}
+		})
+	}
+}
+
+
+func TestScaleMetricsBusy(t *testing.T) {
+	got := make(chan ScaleMetrics, 1)
+	policy := ScalePolicyFunc(func(m ScaleMetrics) int32 {
+		select {
+			case got <- m:
+			default:
+		}
+		return m.Workers
+	})
+	tp := startPool(t, WithAutoscale(AutoscaleOptions{MinWorkers: 3, MaxWorkers: 3, Interval: 10 * time.Millisecond, Policy: policy}))
+
+	started, release := make(chan struct{}), make(chan struct{})
+	defer close(release)
+	if err := tp.Submit(context.Background(), blockJob(started, release)); err != nil {
+		t.Fatalf("Submit(): %v", err)
+	}
+	<-started
+
+	// metrics taken before the job started are dropped.
+	<-got
+	m := <-got
+	if (m.Workers != 3) || (m.Busy != 1) || (m.Idle != 2) {
+		t.Fatalf("workers %d, busy %d, idle %d, want 3, 1, 2", m.Workers, m.Busy, m.Idle)
+	}
+}
+
+
+func TestAutoscale(t *testing.T) {
+	as := AutoscaleOptions{
+		MinWorkers: 1,
+		MaxWorkers: 4,
+		Interval: 5 * time.Millisecond,
+		Policy: QueueScalePolicy{QueueWait: time.Millisecond, IdleFor: 20 * time.Millisecond},
+	}
+	tp := startPool(t, WithAutoscale(as))
+
+	release := make(chan struct{})
+	for i := 0; i < 8; i++ {
+		if err := tp.Submit(context.Background(), blockJob(nil, release)); err != nil {
+			t.Fatalf("Submit(): %v", err)
+		}
+	}
+	waitFor(t, "scaled up to the maximum", func() bool {
+		return tp.GetSize() == as.MaxWorkers
+	})
+
+	close(release)
+	waitFor(t, "scaled down to the minimum", func() bool {
+		return tp.GetSize() == as.MinWorkers
+	})
+}
@@ -0,0 +1,315 @@
+/* *****************************************************************************
+Copyright (c) 2023, sameeroak1110 (sameeroak1110@gmail.com)
+BSD 3-Clause License.
+
+Package     : github.com/sameeroak1110/gowp
+Filename    : github.com/sameeroak1110/gowp/cancel.go
+File-type   : GoLang source code file
+
+Compiler/Runtime: go version go1.20.5 linux/amd64
+
+Version History
+Version     : 1.0
+Author      : Sameer Oak (sameeroak1110@gmail.com)
+
+Description :
+- Cancellation of individual jobs by ID, Cancel(), or by a predicate, CancelWhere().
+- A job that's still waiting - queued, parked behind its key, or scheduled - is removed and its
+handle is resolved with ErrJobCancelled right away. A running job has its context cancelled, it's
+up to the job to return early.
+- Jobs are looked up in the registry of the worker-pool, see registry.go. A job's record tells
+where it is in its life-cycle, thus a job that's in between, e.g., popped and not yet run, is
+caught as well.
+***************************************************************************** */
+package gowp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// JobRef describes a job to the predicate of CancelWhere(), and a job it has cancelled.
+type JobRef struct {
+	ID       uint64
+	Name     string
+	Tenant   string
+	Key      string
+	Metadata map[string]string  // read only.
+	Running  bool               // true if the job is running, false if it's waiting.
+}
+
+
+// true if the job has been cancelled.
+func (rec *jobRecord) isCancelled() bool {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	return rec.cancelled
+}
+
+
+// describes the job. rec.mu must be held.
+func refOf(j Job) JobRef {
+	return JobRef {
+		ID: j.id,
+		Name: j.name,
+		Tenant: j.tenant,
+		Key: j.key,
+		Metadata: j.metadata,
+		Running: j.rec.cancel != nil,
+	}
+}
+
+
+/* *****************************************************************************
+Description : Creates the context the job is run with, and records that it's running.
+
+Receiver    : *WorkerPool
+
+Implements  : NA
+
+Arguments   :
+1> job Job: Job about to be run.
+2> wid int32: ID of the worker the job is assigned to.
+
+Return value:
+1> context.Context: Derived from the worker-pool context, also cancelled along with the job's own
+context and through Cancel().
+2> error: ErrJobCancelled if the job has been cancelled through Cancel(), or ErrJobCancelled
+wrapping the job's own context error. The job isn't run then.
+
+Additional note: NA
+***************************************************************************** */
+func (pwp *WorkerPool) beginRun(job Job, wid int32) (context.Context, error) {
+	if err := job.ctxErr(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrJobCancelled, err)
+	}
+
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if job.ctx != nil {
+		ctx, cancel = mergeCancel(pwp.GetContext(), job.ctx)
+	} else {
+		ctx, cancel = context.WithCancel(pwp.GetContext())
+	}
+
+	job.rec.mu.Lock()
+	defer job.rec.mu.Unlock()
+
+	if job.rec.cancelled {
+		cancel()
+		return nil, ErrJobCancelled
+	}
+	job.rec.cancel = cancel  // released by endRun().
+	job.rec.state = JobRunning
+	job.rec.startedAt = time.Now()
+	job.rec.wid = wid
+	pwp.stats.started()
+
+	return ctx, nil
+}
+
+
+// records that the job has been run and releases its context. a cancelled job's context error is
+// reported as ErrJobCancelled, so is the error of a job whose own context is done, e.g., the rest
+// of a GroupFailFast group once one of its jobs fails. thus, it's neither failed nor timed out.
+func (pwp *WorkerPool) endRun(job Job, js *JobStatus) {
+	job.rec.mu.Lock()
+	cancel := job.rec.cancel
+	job.rec.cancel = nil
+	job.rec.ran = true
+	cancelled := job.rec.cancelled
+	job.rec.mu.Unlock()
+
+	cancel()
+	ctxerr := errors.Is(js.err, context.Canceled) || errors.Is(js.err, context.DeadlineExceeded)
+	switch {
+		case cancelled && errors.Is(js.err, context.Canceled):
+			js.data, js.err = nil, ErrJobCancelled
+
+		case ctxerr && (job.ctxErr() != nil):
+			js.data, js.err = nil, fmt.Errorf("%w: %w", ErrJobCancelled, js.err)
+	}
+
+	return
+}
+
+
+// marks the job cancelled. a running job has its context cancelled. false if the job has been
+// run, or cancelled, already.
+func (pwp *WorkerPool) markCancelled(j Job) (JobRef, bool) {
+	j.rec.mu.Lock()
+	defer j.rec.mu.Unlock()
+
+	if j.rec.cancelled || j.rec.ran || j.rec.done {
+		return JobRef{}, false
+	}
+	j.rec.cancelled = true
+
+	ref := refOf(j)
+	if ref.Running {
+		j.rec.cancel()
+	}
+
+	return ref, true
+}
+
+
+// removes the cancelled jobs from wherever they wait, and resolves their handles with err. jobs
+// already popped and not yet run aren't found, they're resolved here as well and skipped once
+// dispatched.
+func (pwp *WorkerPool) purgeCancelled(jobs []Job, err error) {
+	isCancelled := func(j Job) bool {
+		return j.rec.isCancelled()
+	}
+
+	pwp.qmu.Lock()
+	if len(pwp.jobq.removeWhere(isCancelled)) > 0 {
+		pwp.notifySpace()
+	}
+	pwp.qmu.Unlock()
+
+	pwp.kmu.Lock()
+	for _, box := range pwp.kboxes {
+		box.removeWhere(isCancelled)
+	}
+	pwp.kmu.Unlock()
+
+	pwp.smu.Lock()
+	pwp.removeScheduledWhere(isCancelled)
+	pwp.smu.Unlock()
+
+	for _, j := range jobs {
+		pwp.resolve(j, JobStatus{id: j.id, name: j.name, err: err})
+	}
+
+	return
+}
+
+
+// cancels the job unless it's running, and resolves its handle with err. false if there's no such
+// job, or it's running, has been run, or cancelled, already.
+func (pwp *WorkerPool) cancelWaiting(id uint64, err error) bool {
+	pwp.lmu.Lock()
+	j, ok := pwp.live[id]
+	pwp.lmu.Unlock()
+
+	if !ok {
+		return false
+	}
+
+	j.rec.mu.Lock()
+	if j.rec.cancelled || j.rec.ran || j.rec.done || (j.rec.cancel != nil) {
+		j.rec.mu.Unlock()
+		return false
+	}
+	j.rec.cancelled = true
+	j.rec.mu.Unlock()
+
+	pwp.purgeCancelled([]Job{j}, err)
+
+	return true
+}
+
+
+/* *****************************************************************************
+Description : Cancels a job by ID.
+
+Receiver    : *WorkerPool
+
+Implements  : NA
+
+Arguments   :
+1> id uint64: ID of the job, see JobHandle.GetID().
+
+Return value:
+1> bool: true if the job has been cancelled. false if there's no such job, or it has been run,
+or cancelled, already.
+
+Additional note:
+- A waiting job, whether queued, parked behind its key, or scheduled, is removed and its handle
+is resolved with ErrJobCancelled.
+- A running job has its context cancelled. Its handle is resolved once it returns, with
+ErrJobCancelled if it returns the context error.
+***************************************************************************** */
+func (pwp *WorkerPool) Cancel(id uint64) bool {
+	pwp.lmu.Lock()
+	j, ok := pwp.live[id]
+	pwp.lmu.Unlock()
+
+	if !ok {
+		return false
+	}
+
+	ref, ok := pwp.markCancelled(j)
+	if !ok {
+		return false
+	}
+
+	if !ref.Running {
+		pwp.purgeCancelled([]Job{j}, ErrJobCancelled)
+	}
+
+	return true
+}
+
+
+/* *****************************************************************************
+Description : Cancels the jobs that match a predicate.
+
+Receiver    : *WorkerPool
+
+Implements  : NA
+
+Arguments   :
+1> pred func(JobRef) bool: true for the jobs to be cancelled. Invoked once for each job that's
+waiting or running.
+
+Return value:
+1> []JobRef: Jobs that have been cancelled, by ID.
+
+Additional note:
+Same as Cancel() for each matching job.
+***************************************************************************** */
+func (pwp *WorkerPool) CancelWhere(pred func(JobRef) bool) []JobRef {
+	pwp.lmu.Lock()
+	jobs := make([]Job, 0, len(pwp.live))
+	for _, j := range pwp.live {
+		jobs = append(jobs, j)
+	}
+	pwp.lmu.Unlock()
+
+	var refs []JobRef
+	var waiting []Job
+	for _, j := range jobs {
+		j.rec.mu.Lock()
+		ref := refOf(j)
+		j.rec.mu.Unlock()
+
+		if !pred(ref) {
+			continue
+		}
+
+		ref, ok := pwp.markCancelled(j)
+		if !ok {
+			continue
+		}
+		refs = append(refs, ref)
+		if !ref.Running {
+			waiting = append(waiting, j)
+		}
+	}
+
+	if len(waiting) > 0 {
+		pwp.purgeCancelled(waiting, ErrJobCancelled)
+	}
+
+	sort.Slice(refs, func(i, k int) bool {
+		return refs[i].ID < refs[k].ID
+	})
+
+	return refs
+}
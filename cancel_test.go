@@ -0,0 +1,185 @@
+package gowp
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCancel(t *testing.T) {
+	tests := []struct {
+		name string
+		where string  // where the job is once it's cancelled.
+	}{
+		{"running", "running"},
+		{"queued", "queued"},
+		{"parked behind its key", "keyed"},
+		{"scheduled", "scheduled"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tp := startPool(t, WithWorkers(1))
+
+			started, release := make(chan struct{}), make(chan struct{})
+			var opts []SubmitOption
+			if tt.where == "keyed" {
+				opts = append(opts, WithKey("k"))
+			}
+			blocker, err := tp.SubmitWithHandle(context.Background(), blockJob(started, release), opts...)
+			if err != nil {
+				t.Fatalf("SubmitWithHandle(): %v", err)
+			}
+			<-started
+
+			var ran int32
+			job := Func("cancelled", func(context.Context) (interface{}, error) {
+				atomic.AddInt32(&ran, 1)
+				return nil, nil
+			})
+			var h *JobHandle
+			switch tt.where {
+				case "running":
+					h = blocker
+				case "scheduled":
+					h, err = tp.SubmitAfter(time.Hour, job)
+				default:
+					h, err = tp.SubmitWithHandle(context.Background(), job, opts...)
+			}
+			if err != nil {
+				t.Fatalf("submit: %v", err)
+			}
+
+			if !tp.Cancel(h.GetID()) {
+				t.Fatal("Cancel() = false")
+			}
+			if tp.Cancel(h.GetID()) {
+				t.Fatal("Cancel() = true for a job cancelled already")
+			}
+			if _, err := wait(t, h); !errors.Is(err, ErrJobCancelled) {
+				t.Fatalf("job: %v, want ErrJobCancelled", err)
+			}
+			if ji, _ := tp.JobInfo(h.GetID()); ji.State != JobCancelled {
+				t.Fatalf("job %s, want cancelled", ji.State)
+			}
+
+			// the worker-pool carries on.
+			close(release)
+			if v, err := wait(t, mustSubmit(t, tp, valueJob(1))); (err != nil) || (v != 1) {
+				t.Fatalf("next job: %v, %v", v, err)
+			}
+			if n := atomic.LoadInt32(&ran); n != 0 {
+				t.Fatal("cancelled job ran")
+			}
+		})
+	}
+}
+
+
+func TestCancelFinished(t *testing.T) {
+	tp := startPool(t, WithWorkers(1))
+
+	h := mustSubmit(t, tp, valueJob(1))
+	wait(t, h)
+	if tp.Cancel(h.GetID()) {
+		t.Fatal("Cancel() = true for a finished job")
+	}
+	if tp.Cancel(12345) {
+		t.Fatal("Cancel() = true for an unknown job")
+	}
+}
+
+
+func TestCancelWhere(t *testing.T) {
+	tp := startPool(t, WithWorkers(1))
+
+	started := make(chan struct{})
+	running := mustSubmit(t, tp, blockJob(started, nil), WithTenant("a"))
+	<-started
+
+	tenants := []string{"a", "b", "a", "b", "a"}
+	var hs []*JobHandle
+	for _, tenant := range tenants {
+		hs = append(hs, mustSubmit(t, tp, valueJob(tenant), WithTenant(tenant)))
+	}
+
+	refs := tp.CancelWhere(func(ref JobRef) bool {
+		return ref.Tenant == "a"
+	})
+	if len(refs) != 4 {
+		t.Fatalf("%d jobs cancelled, want 4", len(refs))
+	}
+	for i, ref := range refs {
+		if (i > 0) && (refs[i - 1].ID >= ref.ID) {
+			t.Fatalf("cancelled jobs %+v, want them by ID", refs)
+		}
+		if ref.Running != (ref.ID == running.GetID()) {
+			t.Fatalf("job %d running %t", ref.ID, ref.Running)
+		}
+	}
+
+	if _, err := wait(t, running); !errors.Is(err, ErrJobCancelled) {
+		t.Fatalf("running job: %v, want ErrJobCancelled", err)
+	}
+	for i, h := range hs {
+		_, err := wait(t, h)
+		if cancelled := tenants[i] == "a"; cancelled != errors.Is(err, ErrJobCancelled) {
+			t.Fatalf("job of tenant %s: %v", tenants[i], err)
+		}
+	}
+
+	if refs := tp.CancelWhere(func(JobRef) bool { return true }); len(refs) != 0 {
+		t.Fatalf("%d jobs cancelled once none is left", len(refs))
+	}
+}
+
+
+func TestCancelDuringBackoff(t *testing.T) {
+	tests := []struct {
+		name string
+		own bool  // cancelled through its own context rather than Cancel().
+	}{
+		{"Cancel()", false},
+		{"own context", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sink := NewMemoryDeadLetterSink()
+			tp := startPool(t, WithWorkers(1), WithDeadLetterSink(sink, nil),
+				WithRetryPolicy(&RetryPolicy{MaxAttempts: 5, InitialBackoff: time.Hour}))
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			var n int32
+			h := mustSubmit(t, tp, retryerJob{n: 10, attempts: &n}, WithContext(ctx))
+			waitFor(t, "first attempt", func() bool {
+				return atomic.LoadInt32(&n) == 1
+			})
+			waitFor(t, "backoff", func() bool {
+				ji, _ := tp.JobInfo(h.GetID())
+				return ji.Attempts == 1
+			})
+			if tt.own {
+				cancel()
+			} else if !tp.Cancel(h.GetID()) {
+				t.Fatal("Cancel() = false")
+			}
+
+			if _, err := wait(t, h); !errors.Is(err, ErrJobCancelled) {
+				t.Fatalf("job: %v, want ErrJobCancelled", err)
+			}
+			if ji, _ := tp.JobInfo(h.GetID()); ji.State != JobCancelled {
+				t.Fatalf("job %s, want cancelled", ji.State)
+			}
+			if s := tp.Stats(); (s.Cancelled != 1) || (s.Failed != 0) {
+				t.Fatalf("Stats() %d cancelled, %d failed, want 1, 0", s.Cancelled, s.Failed)
+			}
+			if dls, _ := sink.List(); len(dls) != 0 {
+				t.Fatalf("cancelled job dead-lettered: %+v", dls)
+			}
+		})
+	}
+}
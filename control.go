@@ -0,0 +1,176 @@
+/* *****************************************************************************
+Copyright (c) 2023, sameeroak1110 (sameeroak1110@gmail.com)
+BSD 3-Clause License.
+
+Package     : github.com/sameeroak1110/gowp
+Filename    : github.com/sameeroak1110/gowp/control.go
+File-type   : GoLang source code file
+
+Compiler/Runtime: go version go1.20.5 linux/amd64
+
+Version History
+Version     : 1.0
+Author      : Sameer Oak (sameeroak1110@gmail.com)
+
+Description :
+- JobControl, the job scoped capabilities passed to Process() of a JobProcessorV2.
+- Unlike the raw cancel function passed to a JobProcessor, a job can't kill the worker-pool. It
+can only request a graceful stop, i.e., the worker-pool is drained as through Shutdown().
+- JobProcessorV2 jobs are submitted through V2() adapter. Internally, the worker-pool runs each job
+as a JobProcessorV2, JobProcessor jobs are adapted to it.
+***************************************************************************** */
+package gowp
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// progress reported by a job, see JobControl.ReportProgress().
+type Progress struct {
+	JobID   uint64
+	JobName string
+	Percent float64    // 0 through 100.
+	Message string
+	At      time.Time  // when the progress was reported.
+}
+
+// job scoped capabilities passed to Process() of a JobProcessorV2.
+type jobControl struct {
+	pwp *WorkerPool
+	job Job
+	attempt int
+}
+
+// adapts a JobProcessorV2 to JobProcessor, see V2().
+type v2Job struct {
+	p JobProcessorV2
+}
+
+// adapts a JobProcessor to JobProcessorV2. the job receives the worker-pool wide parameters.
+type v1Job struct {
+	p JobProcessor
+	pwp *WorkerPool
+}
+
+
+// V2 adapts a JobProcessorV2 so that it can be submitted to the worker-pool.
+func V2(p JobProcessorV2) JobProcessor {
+	return v2Job{p: p}
+}
+
+
+func (j v2Job) GetName() string {
+	return j.p.GetName()
+}
+
+
+// Process runs the job outside a worker-pool. JobControl then has job ID 0 and can't stop any
+// worker-pool. The worker-pool rather invokes Process() of the adapted JobProcessorV2 itself.
+func (j v2Job) Process(ctx context.Context, _ context.CancelFunc, _ int, _ bool) (interface{}, error) {
+	return j.p.Process(ctx, &jobControl{attempt: AttemptFromContext(ctx)})
+}
+
+
+func (j v1Job) GetName() string {
+	return j.p.GetName()
+}
+
+
+func (j v1Job) Process(ctx context.Context, _ JobControl) (interface{}, error) {
+	return j.p.Process(ctx, j.pwp.cancelFunc, j.pwp.maxJobCnt, j.pwp.shouldTerminate)
+}
+
+
+// returns the job as a JobProcessorV2.
+func (pwp *WorkerPool) processorOf(job Job) JobProcessorV2 {
+	if j, ok := job.data.(v2Job); ok {
+		return j.p
+	}
+
+	return v1Job{p: job.data, pwp: pwp}
+}
+
+
+// returns the original job, unwrapping the V2() adapter. Retryer, Timeouter, and others are
+// looked up on it.
+func unwrapJob(p JobProcessor) interface{} {
+	if j, ok := p.(v2Job); ok {
+		return j.p
+	}
+
+	return p
+}
+
+
+func (c *jobControl) JobID() uint64 {
+	return c.job.id
+}
+
+
+func (c *jobControl) Attempt() int {
+	return c.attempt
+}
+
+
+func (c *jobControl) RequestStop() {
+	if c.pwp != nil {
+		c.pwp.requestStop()
+	}
+
+	return
+}
+
+
+func (c *jobControl) ReportProgress(percent float64, msg string) {
+	p := Progress {
+		JobID: c.job.id,
+		JobName: c.job.name,
+		Percent: percent,
+		Message: msg,
+		At: time.Now(),
+	}
+	c.job.handle.setProgress(p)
+
+	if (c.pwp != nil) && (c.pwp.onProgress != nil) {
+		c.pwp.onProgress(p)
+	}
+
+	return
+}
+
+
+func (c *jobControl) Metadata(key string) (string, bool) {
+	v, ok := c.job.metadata[key]
+	return v, ok
+}
+
+
+// shuts the worker-pool down in the background, once. running jobs, including the requesting
+// one, aren't affected.
+func (pwp *WorkerPool) requestStop() {
+	if !atomic.CompareAndSwapInt32(&pwp.stopRequested, 0, 1) {
+		return
+	}
+
+	go func() {
+		pwp.Shutdown(context.Background())
+		atomic.StoreInt32(&pwp.stopRequested, 0)  // the worker-pool may be restarted.
+	}()
+
+	return
+}
+
+
+// WithMetadata attaches key-value pairs to the job, see JobControl.Metadata(). The map is copied.
+func WithMetadata(md map[string]string) SubmitOption {
+	return func(sc *submitConfig) {
+		if sc.metadata == nil {
+			sc.metadata = make(map[string]string, len(md))
+		}
+		for k, v := range md {
+			sc.metadata[k] = v
+		}
+	}
+}
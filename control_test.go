@@ -0,0 +1,182 @@
+package gowp
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// JobProcessorV2 that runs f, with its own retry policy if rp is set.
+type ctlJob struct {
+	f func(context.Context, JobControl) (interface{}, error)
+	rp *RetryPolicy
+}
+
+func (j ctlJob) GetName() string {
+	return "ctl"
+}
+
+func (j ctlJob) Process(ctx context.Context, ctl JobControl) (interface{}, error) {
+	return j.f(ctx, ctl)
+}
+
+func (j ctlJob) RetryPolicy() *RetryPolicy {
+	return j.rp
+}
+
+
+// JobProcessor that returns the worker-pool wide parameters it receives.
+type v1ParamsJob struct{}
+
+type v1Params struct {
+	cancel bool
+	maxJobCnt int
+	shouldTerminate bool
+}
+
+func (j v1ParamsJob) GetName() string {
+	return "v1"
+}
+
+func (j v1ParamsJob) Process(_ context.Context, cancel context.CancelFunc, maxJobCnt int, shouldTerminate bool) (interface{}, error) {
+	return v1Params{cancel != nil, maxJobCnt, shouldTerminate}, nil
+}
+
+
+func TestJobControl(t *testing.T) {
+	tp := startPool(t, WithWorkers(1))
+
+	type seen struct {
+		id uint64
+		attempt int
+		region string
+		regionOK, otherOK bool
+	}
+	md := map[string]string{"region": "eu"}
+	ch := make(chan seen, 1)
+	h := mustSubmit(t, tp, V2(ctlJob{f: func(_ context.Context, ctl JobControl) (interface{}, error) {
+		s := seen{id: ctl.JobID(), attempt: ctl.Attempt()}
+		s.region, s.regionOK = ctl.Metadata("region")
+		_, s.otherOK = ctl.Metadata("other")
+		ch <- s
+		return nil, nil
+	}}), WithMetadata(md))
+	md["region"] = "us"  // the job has its own copy.
+
+	wait(t, h)
+	s := <-ch
+	if (s.id != h.GetID()) || (s.attempt != 1) {
+		t.Fatalf("JobID() = %d, Attempt() = %d, want %d, 1", s.id, s.attempt, h.GetID())
+	}
+	if (s.region != "eu") || !s.regionOK || s.otherOK {
+		t.Fatalf("Metadata(): region %q %t, other %t", s.region, s.regionOK, s.otherOK)
+	}
+}
+
+
+func TestJobControlAttempt(t *testing.T) {
+	tp := startPool(t, WithWorkers(1))
+
+	var mu sync.Mutex
+	var attempts []int
+	job := ctlJob{rp: &RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}}
+	job.f = func(_ context.Context, ctl JobControl) (interface{}, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		attempts = append(attempts, ctl.Attempt())
+		if len(attempts) < 3 {
+			return nil, errTest
+		}
+		return nil, nil
+	}
+
+	if _, err := wait(t, mustSubmit(t, tp, V2(job))); err != nil {
+		t.Fatalf("job: %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if (len(attempts) != 3) || (attempts[0] != 1) || (attempts[2] != 3) {
+		t.Fatalf("attempts %v, want [1 2 3]", attempts)
+	}
+}
+
+
+func TestReportProgress(t *testing.T) {
+	var mu sync.Mutex
+	var reported []Progress
+	tp := startPool(t, WithWorkers(1), WithOnProgress(func(p Progress) {
+		mu.Lock()
+		reported = append(reported, p)
+		mu.Unlock()
+	}))
+
+	h := mustSubmit(t, tp, V2(ctlJob{f: func(_ context.Context, ctl JobControl) (interface{}, error) {
+		ctl.ReportProgress(50, "half")
+		ctl.ReportProgress(100, "done")
+		return nil, nil
+	}}))
+	wait(t, h)
+
+	p, ok := h.Progress()
+	if !ok || (p.Percent != 100) || (p.Message != "done") || (p.JobID != h.GetID()) {
+		t.Fatalf("Progress() = %+v, %t", p, ok)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if (len(reported) != 2) || (reported[0].Percent != 50) {
+		t.Fatalf("OnProgress() invoked with %+v", reported)
+	}
+}
+
+
+func TestRequestStop(t *testing.T) {
+	tp := startPool(t, WithWorkers(1))
+
+	started, release := make(chan struct{}), make(chan struct{})
+	blocker := mustSubmit(t, tp, blockJob(started, release))
+	<-started
+	queued := mustSubmit(t, tp, valueJob(1))
+	stopper := mustSubmit(t, tp, V2(ctlJob{f: func(_ context.Context, ctl JobControl) (interface{}, error) {
+		ctl.RequestStop()
+		ctl.RequestStop()
+		return nil, nil
+	}}))
+	close(release)
+
+	// the worker-pool drains, jobs queued before the request run.
+	for _, h := range []*JobHandle{blocker, queued, stopper} {
+		if _, err := wait(t, h); err != nil {
+			t.Fatalf("job %d: %v", h.GetID(), err)
+		}
+	}
+	tp.waitStart(t)
+	if s := tp.State(); s != StateStopped {
+		t.Fatalf("state %s once stop is requested, want stopped", s)
+	}
+}
+
+
+func TestV1Params(t *testing.T) {
+	tp := startPool(t, WithWorkers(1), WithMaxJobCnt(5, true))
+
+	v, err := wait(t, mustSubmit(t, tp, v1ParamsJob{}))
+	if (err != nil) || (v != v1Params{true, 5, true}) {
+		t.Fatalf("job: %+v, %v", v, err)
+	}
+}
+
+
+func TestV2OutsidePool(t *testing.T) {
+	job := V2(ctlJob{f: func(_ context.Context, ctl JobControl) (interface{}, error) {
+		ctl.RequestStop()  // there's no worker-pool to stop.
+		return ctl.JobID(), nil
+	}})
+
+	if v, err := job.Process(context.Background(), nil, 0, false); (err != nil) || (v != uint64(0)) {
+		t.Fatalf("Process(): %v, %v", v, err)
+	}
+	if job.GetName() != "ctl" {
+		t.Fatalf("GetName() = %q", job.GetName())
+	}
+}
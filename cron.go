@@ -0,0 +1,607 @@
+/* *****************************************************************************
+Copyright (c) 2023, sameeroak1110 (sameeroak1110@gmail.com)
+BSD 3-Clause License.
+
+Package     : github.com/sameeroak1110/gowp
+Filename    : github.com/sameeroak1110/gowp/cron.go
+File-type   : GoLang source code file
+
+Compiler/Runtime: go version go1.20.5 linux/amd64
+
+Version History
+Version     : 1.0
+Author      : Sameer Oak (sameeroak1110@gmail.com)
+
+Description :
+- Recurring jobs. A cron entry, added through AddCron(), submits a job built by its factory on
+each tick of its schedule.
+- Schedules are standard cron expressions of 5 fields (minute hour day-of-month month
+day-of-week) or 6 fields (second first), the descriptors @yearly, @annually, @monthly, @weekly,
+@daily, @midnight, and @hourly, or "@every <duration>". An expression may be prefixed with
+"CRON_TZ=<zone> " or "TZ=<zone> " to be evaluated in that time zone. CronOptions.Location may
+only repeat that zone.
+- A cron go-routine, started along with the first entry, sleeps until the earliest next run and
+then submits the jobs of the due entries. It lasts as long as the worker-pool context.
+***************************************************************************** */
+package gowp
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidCronSpec = errors.New("gowp: invalid cron expression")
+
+// CronSchedule returns the next run after t, zero time if there isn't one.
+type CronSchedule interface {
+	Next(t time.Time) time.Time
+}
+
+// OverlapPolicy decides what a tick does while the job of the previous tick is still queued or running.
+type OverlapPolicy int
+
+const (
+	OverlapAllow OverlapPolicy = iota  // job is submitted anyway, the jobs may run concurrently.
+	OverlapSkip                        // tick is skipped.
+	OverlapQueue                       // job is submitted, it runs once the previous ones finish.
+)
+
+// Cron entry configuration, see AddCron().
+type CronOptions struct {
+	Location *time.Location   // time zone the schedule is evaluated in. default is the zone in the expression, else time.Local. must not conflict with the zone in the expression.
+	Overlap  OverlapPolicy
+	Submit   []SubmitOption   // options of each submitted job.
+}
+
+// CronID identifies a cron entry.
+type CronID uint64
+
+// CronEntry describes a cron entry, see CronEntries().
+type CronEntry struct {
+	ID      CronID
+	Spec    string
+	Next    time.Time  // next run, zero if there isn't one.
+	Prev    time.Time  // previous run, zero if it hasn't run yet.
+	Runs    uint64     // no. of jobs submitted.
+	Skipped uint64     // no. of ticks skipped as per the overlap policy, or as the job couldn't be submitted.
+	LastErr error      // error of the last failed submission.
+}
+
+// schedule of a cron expression. each field is a bit set of the matching values.
+type specSchedule struct {
+	second, minute, hour, dom, month, dow uint64
+	loc *time.Location   // nil means the zone of the time passed to Next().
+}
+
+// schedule of "@every <duration>".
+type everySchedule struct {
+	d time.Duration
+}
+
+// a cron entry.
+type cronEntry struct {
+	id CronID
+	spec string
+	sched CronSchedule
+	loc *time.Location
+	factory func() JobProcessor
+	overlap OverlapPolicy
+	submit []SubmitOption
+	next time.Time
+	prev time.Time
+	inflight int   // no. of submitted jobs yet to finish.
+	runs uint64
+	skipped uint64
+	lastErr error
+}
+
+// bounds of a cron field.
+type cronField struct {
+	name string
+	min, max uint
+	names map[string]uint
+}
+
+var cronSeconds = cronField{"second", 0, 59, nil}
+var cronMinutes = cronField{"minute", 0, 59, nil}
+var cronHours = cronField{"hour", 0, 23, nil}
+var cronDoms = cronField{"day-of-month", 1, 31, nil}
+var cronMonths = cronField{"month", 1, 12, map[string]uint {
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+	"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+}}
+var cronDows = cronField{"day-of-week", 0, 7, map[string]uint {
+	"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+}}
+
+const starBit uint64 = 1 << 63   // set if the field is "*" or "?".
+
+var cronDescriptors = map[string]string {
+	"@yearly": "0 0 0 1 1 *",
+	"@annually": "0 0 0 1 1 *",
+	"@monthly": "0 0 0 1 * *",
+	"@weekly": "0 0 0 * * 0",
+	"@daily": "0 0 0 * * *",
+	"@midnight": "0 0 0 * * *",
+	"@hourly": "0 0 * * * *",
+}
+
+
+/* *****************************************************************************
+Description : Parses a cron expression.
+
+Receiver    : NA
+
+Implements  : NA
+
+Arguments   :
+1> spec string: Cron expression, see the file header for the syntax.
+
+Return value:
+1> CronSchedule: Schedule of the expression.
+2> error: Descriptive error, wrapping ErrInvalidCronSpec, if the expression is invalid.
+
+Additional note:
+- If both day-of-month and day-of-week are restricted, a day matching either of them matches.
+- Times that fall in a daylight saving gap of the time zone don't exist, they're skipped.
+***************************************************************************** */
+func ParseCron(spec string) (CronSchedule, error) {
+	s := strings.TrimSpace(spec)
+
+	var loc *time.Location
+	if strings.HasPrefix(s, "CRON_TZ=") || strings.HasPrefix(s, "TZ=") {
+		i := strings.IndexAny(s, " \t")
+		if i < 0 {
+			return nil, fmt.Errorf("%w: %q: missing fields after time zone", ErrInvalidCronSpec, spec)
+		}
+
+		var err error
+		zone := s[strings.Index(s, "=")+1 : i]
+		if zone == "" {
+			return nil, fmt.Errorf("%w: %q: missing time zone", ErrInvalidCronSpec, spec)
+		}
+		if loc, err = time.LoadLocation(zone); err != nil {
+			return nil, fmt.Errorf("%w: %q: time zone %q: %s", ErrInvalidCronSpec, spec, zone, err.Error())
+		}
+		s = strings.TrimSpace(s[i:])
+	}
+
+	if strings.HasPrefix(s, "@every ") {
+		d, err := time.ParseDuration(strings.TrimSpace(s[len("@every "):]))
+		if (err != nil) || (d <= 0) {
+			return nil, fmt.Errorf("%w: %q: @every needs a positive duration", ErrInvalidCronSpec, spec)
+		}
+		return everySchedule{d: d}, nil
+	}
+
+	if desc, ok := cronDescriptors[s]; ok {
+		s = desc
+	}
+
+	fields := strings.Fields(s)
+	switch len(fields) {
+		case 5:
+			fields = append([]string{"0"}, fields...)
+		case 6:
+		default:
+			return nil, fmt.Errorf("%w: %q: expected 5 or 6 fields, found %d", ErrInvalidCronSpec, spec, len(fields))
+	}
+
+	sched := &specSchedule{loc: loc}
+	var err error
+	for i, f := range []struct{ p *uint64; cf cronField } {
+		{&sched.second, cronSeconds}, {&sched.minute, cronMinutes}, {&sched.hour, cronHours},
+		{&sched.dom, cronDoms}, {&sched.month, cronMonths}, {&sched.dow, cronDows},
+	} {
+		if *f.p, err = parseCronField(fields[i], f.cf); err != nil {
+			return nil, fmt.Errorf("%w: %q: %s", ErrInvalidCronSpec, spec, err.Error())
+		}
+	}
+
+	// sunday is either 0 or 7.
+	if sched.dow & (1 << 7) != 0 {
+		sched.dow = (sched.dow &^ (1 << 7)) | 1
+	}
+
+	return sched, nil
+}
+
+
+// parses a field, a comma separated list of "*", "?", "a", "a-b", each optionally followed by "/step".
+func parseCronField(field string, cf cronField) (uint64, error) {
+	var bits uint64
+	for _, term := range strings.Split(field, ",") {
+		rng, step := term, uint(1)
+		if i := strings.Index(term, "/"); i >= 0 {
+			n, err := strconv.ParseUint(term[i+1:], 10, 8)
+			if (err != nil) || (n == 0) {
+				return 0, fmt.Errorf("%s: invalid step in %q", cf.name, term)
+			}
+			rng, step = term[:i], uint(n)
+		}
+
+		var lo, hi uint
+		switch {
+			case (rng == "*") || (rng == "?"):
+				lo, hi = cf.min, cf.max
+				if step == 1 {
+					bits |= starBit
+				}
+
+			case strings.Contains(rng, "-"):
+				i := strings.Index(rng, "-")
+				var err error
+				if lo, err = cronValue(rng[:i], cf); err != nil {
+					return 0, err
+				}
+				if hi, err = cronValue(rng[i+1:], cf); err != nil {
+					return 0, err
+				}
+				if lo > hi {
+					return 0, fmt.Errorf("%s: invalid range %q", cf.name, rng)
+				}
+
+			default:
+				var err error
+				if lo, err = cronValue(rng, cf); err != nil {
+					return 0, err
+				}
+				hi = lo
+				if strings.Contains(term, "/") {
+					hi = cf.max  // "a/n" means from a through the maximum.
+				}
+		}
+
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << v
+		}
+	}
+
+	return bits, nil
+}
+
+
+// parses a value of a field, a number or a name.
+func cronValue(s string, cf cronField) (uint, error) {
+	if v, ok := cf.names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+
+	n, err := strconv.ParseUint(s, 10, 8)
+	if (err != nil) || (uint(n) < cf.min) || (uint(n) > cf.max) {
+		return 0, fmt.Errorf("%s: %q isn't in between %d and %d", cf.name, s, cf.min, cf.max)
+	}
+
+	return uint(n), nil
+}
+
+
+func (s everySchedule) Next(t time.Time) time.Time {
+	return t.Add(s.d)
+}
+
+
+// true if the day of t matches day-of-month and day-of-week.
+func (s *specSchedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom & (1 << uint(t.Day())) != 0
+	dowMatch := s.dow & (1 << uint(t.Weekday())) != 0
+	if (s.dom & starBit != 0) || (s.dow & starBit != 0) {
+		return domMatch && dowMatch
+	}
+
+	return domMatch || dowMatch
+}
+
+
+// advances t to next, the start of a later month, day, or hour. if next falls in a daylight saving
+// gap, time.Date() may normalize it back to t or before. t is advanced to its next whole hour then,
+// thus times in the gap are skipped rather than looped over.
+func cronAdvance(t, next time.Time) time.Time {
+	if next.After(t) {
+		return next
+	}
+
+	return t.Add(time.Hour - time.Duration(t.Minute()) * time.Minute - time.Duration(t.Second()) * time.Second)
+}
+
+
+func (s *specSchedule) Next(t time.Time) time.Time {
+	orig := t.Location()
+	loc := orig
+	if s.loc != nil {
+		loc = s.loc
+	}
+	t = t.In(loc)
+
+	// next whole second.
+	t = t.Add(time.Second - time.Duration(t.Nanosecond()))
+	limit := t.Year() + 5
+
+	for t.Year() <= limit {
+		if s.month & (1 << uint(t.Month())) == 0 {
+			t = cronAdvance(t, time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc))
+			continue
+		}
+
+		if !s.dayMatches(t) {
+			t = cronAdvance(t, time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc))
+			continue
+		}
+
+		if s.hour & (1 << uint(t.Hour())) == 0 {
+			t = cronAdvance(t, time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc))
+			continue
+		}
+
+		if s.minute & (1 << uint(t.Minute())) == 0 {
+			t = t.Truncate(time.Minute).Add(time.Minute)
+			continue
+		}
+
+		if s.second & (1 << uint(t.Second())) == 0 {
+			t = t.Add(time.Second)
+			continue
+		}
+
+		return t.In(orig)
+	}
+
+	return time.Time{}  // schedule doesn't match any time, e.g., 30th of february.
+}
+
+
+/* *****************************************************************************
+Description : Adds a cron entry to the worker-pool.
+
+Receiver    : *WorkerPool
+
+Implements  : NA
+
+Arguments   :
+1> spec string: Cron expression, see ParseCron().
+2> factory func() JobProcessor: Builds the job submitted on each tick.
+3> opts CronOptions: Time zone, overlap policy, and options of the submitted jobs.
+
+Return value:
+1> CronID: ID of the new entry.
+2> error: Descriptive error, wrapping ErrInvalidCronSpec, if the expression is invalid.
+ErrInvalidOption if factory is nil, or if both the expression and opts.Location have a time zone
+and they aren't the same.
+
+Additional note:
+- A tick submits the job without waiting for room in the job queue. If it can't be submitted, the
+tick is counted as skipped and the error is recorded as CronEntry.LastErr.
+- Ticks that fall while the cron go-routine is busy, or the system is suspended, aren't made up
+for. The entry just runs at its next time.
+***************************************************************************** */
+func (pwp *WorkerPool) AddCron(spec string, factory func() JobProcessor, opts CronOptions) (CronID, error) {
+	if factory == nil {
+		return 0, fmt.Errorf("%w: nil cron job factory", ErrInvalidOption)
+	}
+
+	sched, err := ParseCron(spec)
+	if err != nil {
+		return 0, err
+	}
+
+	// time zone of the expression, if any, is the one the schedule is evaluated in.
+	loc := opts.Location
+	if ss, ok := sched.(*specSchedule); ok && (ss.loc != nil) {
+		if (loc != nil) && (loc.String() != ss.loc.String()) {
+			return 0, fmt.Errorf("%w: cron time zone %q conflicts with location %q", ErrInvalidOption,
+				ss.loc.String(), loc.String())
+		}
+		loc = ss.loc
+	}
+	if loc == nil {
+		loc = time.Local
+	}
+
+	pwp.cmu.Lock()
+	pwp.cnextID++
+	e := &cronEntry {
+		id: pwp.cnextID,
+		spec: spec,
+		sched: sched,
+		loc: loc,
+		factory: factory,
+		overlap: opts.Overlap,
+		submit: opts.Submit,
+		next: sched.Next(time.Now().In(loc)),
+	}
+	if e.overlap == OverlapQueue {
+		// jobs of the same key run one at a time, in order.
+		e.submit = append(append([]SubmitOption{}, opts.Submit...), WithKey(fmt.Sprintf("gowp.cron.%d", e.id)))
+	}
+	pwp.crons[e.id] = e
+	pwp.cmu.Unlock()
+
+	pwp.conce.Do(func() {
+		go pwp.runCron()
+	})
+
+	// wakes up the cron go-routine as the new entry may be the earliest.
+	select {
+		case pwp.cwake <- struct{}{}:
+		default:
+	}
+
+	return e.id, nil
+}
+
+
+// RemoveCron removes the cron entry. Its jobs already submitted aren't affected. false if there
+// isn't such an entry.
+func (pwp *WorkerPool) RemoveCron(id CronID) bool {
+	pwp.cmu.Lock()
+	defer pwp.cmu.Unlock()
+
+	if _, ok := pwp.crons[id]; !ok {
+		return false
+	}
+	delete(pwp.crons, id)
+
+	return true
+}
+
+
+func (e *cronEntry) describe() CronEntry {
+	return CronEntry {
+		ID: e.id,
+		Spec: e.spec,
+		Next: e.next,
+		Prev: e.prev,
+		Runs: e.runs,
+		Skipped: e.skipped,
+		LastErr: e.lastErr,
+	}
+}
+
+
+// CronEntries returns the cron entries, in the order of their next run.
+func (pwp *WorkerPool) CronEntries() []CronEntry {
+	pwp.cmu.Lock()
+	entries := make([]CronEntry, 0, len(pwp.crons))
+	for _, e := range pwp.crons {
+		entries = append(entries, e.describe())
+	}
+	pwp.cmu.Unlock()
+
+	sort.Slice(entries, func(i, k int) bool {
+		if entries[i].Next.Equal(entries[k].Next) {
+			return entries[i].ID < entries[k].ID
+		}
+		if entries[i].Next.IsZero() || entries[k].Next.IsZero() {
+			return !entries[i].Next.IsZero()
+		}
+		return entries[i].Next.Before(entries[k].Next)
+	})
+
+	return entries
+}
+
+
+// GetCronEntry returns the cron entry, false if there isn't such an entry.
+func (pwp *WorkerPool) GetCronEntry(id CronID) (CronEntry, bool) {
+	pwp.cmu.Lock()
+	defer pwp.cmu.Unlock()
+
+	e, ok := pwp.crons[id]
+	if !ok {
+		return CronEntry{}, false
+	}
+
+	return e.describe(), true
+}
+
+
+// submits the job of a due entry as per its overlap policy.
+func (pwp *WorkerPool) fireCron(e *cronEntry) {
+	pwp.cmu.Lock()
+	if (e.overlap == OverlapSkip) && (e.inflight > 0) {
+		e.skipped++
+		pwp.cmu.Unlock()
+		return
+	}
+	e.inflight++
+	pwp.cmu.Unlock()
+
+	j := pwp.newJob(e.factory(), e.submit...)
+	err := pwp.enqueue(pwp.GetContext(), j, false)
+
+	pwp.cmu.Lock()
+	defer pwp.cmu.Unlock()
+
+	if err != nil {
+		e.inflight--
+		e.skipped++
+		e.lastErr = err
+		return
+	}
+	e.runs++
+
+	// the job isn't waited for once the worker-pool is stopped, it may not run until it's started again.
+	halted := pwp.haltSignal()
+	go func() {
+		select {
+			case <-j.handle.Done():
+			case <-halted:
+			case <-pwp.GetContext().Done():
+		}
+		pwp.cmu.Lock()
+		e.inflight--
+		pwp.cmu.Unlock()
+	}()
+
+	return
+}
+
+
+/* *****************************************************************************
+Description : Runs the cron entries. Invoked as a go-routine along with the first entry.
+
+Receiver    : *WorkerPool
+
+Implements  : NA
+
+Arguments   : NA
+
+Return value: NA
+
+Additional note: NA
+***************************************************************************** */
+func (pwp *WorkerPool) runCron() {
+	ctx := pwp.GetContext()
+	for {
+		now := time.Now()
+		var earliest time.Time
+		var due []*cronEntry
+
+		pwp.cmu.Lock()
+		for _, e := range pwp.crons {
+			if e.next.IsZero() {
+				continue  // no more runs.
+			}
+
+			if !e.next.After(now) {
+				e.prev = e.next
+				e.next = e.sched.Next(now.In(e.loc))
+				due = append(due, e)
+			}
+
+			if !e.next.IsZero() && (earliest.IsZero() || e.next.Before(earliest)) {
+				earliest = e.next
+			}
+		}
+		pwp.cmu.Unlock()
+
+		for _, e := range due {
+			pwp.fireCron(e)
+		}
+
+		var t *time.Timer
+		var timer <-chan time.Time
+		if !earliest.IsZero() {
+			t = time.NewTimer(time.Until(earliest))
+			timer = t.C
+		}
+
+		select {
+			case <-timer:
+			case <-pwp.cwake:
+			case <-ctx.Done():
+				if t != nil {
+					t.Stop()
+				}
+				return
+		}
+
+		if t != nil {
+			t.Stop()
+		}
+	}
+}
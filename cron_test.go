@@ -0,0 +1,188 @@
+package gowp
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseCronInvalid(t *testing.T) {
+	tests := []string{
+		"",
+		"* * * *",
+		"* * * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * 32 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"*/x * * * *",
+		"5-1 * * * *",
+		"1-2-3 * * * *",
+		"a * * * *",
+		"* * * foo *",
+		"1,,2 * * * *",
+		"@every",
+		"@every 0s",
+		"@every -1m",
+		"@every soon",
+		"@fortnightly",
+		"CRON_TZ=UTC",
+		"CRON_TZ=Nowhere/Atlantis * * * * *",
+		"TZ= * * * * *",
+	}
+
+	for _, spec := range tests {
+		t.Run(spec, func(t *testing.T) {
+			if _, err := ParseCron(spec); !errors.Is(err, ErrInvalidCronSpec) {
+				t.Fatalf("ParseCron(%q): %v, want ErrInvalidCronSpec", spec, err)
+			}
+		})
+	}
+}
+
+
+func TestCronNext(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone database: %v", err)
+	}
+	at := func(loc *time.Location, s string) time.Time {
+		tm, err := time.ParseInLocation("2006-01-02 15:04:05", s, loc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tm
+	}
+	utc := time.UTC
+
+	tests := []struct {
+		name string
+		spec string
+		from time.Time
+		want time.Time  // zero if there's no next run.
+	}{
+		{"every minute", "* * * * *", at(utc, "2023-07-01 10:00:30"), at(utc, "2023-07-01 10:01:00")},
+		{"every second", "* * * * * *", at(utc, "2023-07-01 10:00:30"), at(utc, "2023-07-01 10:00:31")},
+		{"seconds field", "15 * * * * *", at(utc, "2023-07-01 10:00:30"), at(utc, "2023-07-01 10:01:15")},
+		{"step", "*/15 * * * *", at(utc, "2023-07-01 10:07:00"), at(utc, "2023-07-01 10:15:00")},
+		{"step from a value", "5/20 * * * *", at(utc, "2023-07-01 10:06:00"), at(utc, "2023-07-01 10:25:00")},
+		{"range with step", "10-30/10 * * * *", at(utc, "2023-07-01 10:31:00"), at(utc, "2023-07-01 11:10:00")},
+		{"list", "0 8,20 * * *", at(utc, "2023-07-01 09:00:00"), at(utc, "2023-07-01 20:00:00")},
+		{"exact time is excluded", "0 9 * * *", at(utc, "2023-07-01 09:00:00"), at(utc, "2023-07-02 09:00:00")},
+		{"month rollover", "0 0 1 * *", at(utc, "2023-01-31 12:00:00"), at(utc, "2023-02-01 00:00:00")},
+		{"year rollover", "0 0 1 1 *", at(utc, "2023-07-01 00:00:00"), at(utc, "2024-01-01 00:00:00")},
+		{"leap day", "0 0 29 2 *", at(utc, "2023-03-01 00:00:00"), at(utc, "2024-02-29 00:00:00")},
+		{"never", "0 0 30 2 *", at(utc, "2023-01-01 00:00:00"), time.Time{}},
+		{"day-of-month only", "0 0 13 * *", at(utc, "2023-07-01 00:00:00"), at(utc, "2023-07-13 00:00:00")},
+		{"day-of-month or day-of-week", "0 0 13 * 5", at(utc, "2023-07-01 00:00:00"), at(utc, "2023-07-07 00:00:00")},
+		{"sunday as 0", "0 0 * * 0", at(utc, "2023-07-01 12:00:00"), at(utc, "2023-07-02 00:00:00")},
+		{"sunday as 7", "0 0 * * 7", at(utc, "2023-07-01 12:00:00"), at(utc, "2023-07-02 00:00:00")},
+		{"names", "0 0 * feb-mar MON", at(utc, "2023-01-15 00:00:00"), at(utc, "2023-02-06 00:00:00")},
+		{"question mark", "0 0 1 * ?", at(utc, "2023-07-02 00:00:00"), at(utc, "2023-08-01 00:00:00")},
+		{"daily", "@daily", at(utc, "2023-07-01 10:00:00"), at(utc, "2023-07-02 00:00:00")},
+		{"hourly", "@hourly", at(utc, "2023-07-01 10:00:00"), at(utc, "2023-07-01 11:00:00")},
+		{"weekly", "@weekly", at(utc, "2023-07-01 10:00:00"), at(utc, "2023-07-02 00:00:00")},
+		{"yearly", "@yearly", at(utc, "2023-07-01 10:00:00"), at(utc, "2024-01-01 00:00:00")},
+		{"every", "@every 90s", at(utc, "2023-07-01 10:00:00"), at(utc, "2023-07-01 10:01:30")},
+		{"time zone", "CRON_TZ=America/New_York 0 9 * * *", at(utc, "2023-07-01 12:00:00"), at(utc, "2023-07-01 13:00:00")},
+		{"TZ prefix", "TZ=America/New_York 0 9 * * *", at(utc, "2023-07-01 14:00:00"), at(utc, "2023-07-02 13:00:00")},
+		{"zone of from", "0 9 * * *", at(ny, "2023-07-01 08:00:00"), at(ny, "2023-07-01 09:00:00")},
+		{"skipped by daylight saving", "30 2 * * *", at(ny, "2023-03-12 00:00:00"), at(ny, "2023-03-13 02:30:00")},
+		{"repeated by daylight saving", "30 1 * * *", at(ny, "2023-11-05 00:00:00"), at(ny, "2023-11-05 01:30:00")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sched, err := ParseCron(tt.spec)
+			if err != nil {
+				t.Fatalf("ParseCron(%q): %v", tt.spec, err)
+			}
+			got := sched.Next(tt.from)
+			if !got.Equal(tt.want) {
+				t.Fatalf("Next(%s) = %s, want %s", tt.from, got, tt.want)
+			}
+			if !got.IsZero() && (got.Location() != tt.from.Location()) {
+				t.Fatalf("Next() in %s, want %s", got.Location(), tt.from.Location())
+			}
+		})
+	}
+}
+
+
+func TestAddCronLocation(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone database: %v", err)
+	}
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skipf("time zone database: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		spec string
+		loc *time.Location
+		want error
+	}{
+		{"expression zone", "CRON_TZ=America/New_York 0 9 * * *", nil, nil},
+		{"location", "0 9 * * *", ny, nil},
+		{"same zone", "CRON_TZ=America/New_York 0 9 * * *", ny, nil},
+		{"conflicting zones", "CRON_TZ=America/New_York 0 9 * * *", tokyo, ErrInvalidOption},
+		{"invalid expression", "0 9 * *", ny, ErrInvalidCronSpec},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tp := newPool(t, WithWorkers(1))
+			id, err := tp.AddCron(tt.spec, func() JobProcessor { return valueJob(nil) }, CronOptions{Location: tt.loc})
+			if !errors.Is(err, tt.want) || ((tt.want == nil) != (err == nil)) {
+				t.Fatalf("AddCron(): %v, want %v", err, tt.want)
+			}
+			if err != nil {
+				return
+			}
+
+			e, _ := tp.GetCronEntry(id)
+			if h, m := e.Next.In(ny).Hour(), e.Next.In(ny).Minute(); (h != 9) || (m != 0) {
+				t.Fatalf("next run %s, want 09:00 in New York", e.Next.In(ny))
+			}
+		})
+	}
+
+	tp := newPool(t, WithWorkers(1))
+	if _, err := tp.AddCron("* * * * *", nil, CronOptions{}); !errors.Is(err, ErrInvalidOption) {
+		t.Fatalf("AddCron() with a nil factory: %v, want ErrInvalidOption", err)
+	}
+}
+
+
+func TestCronOverlapAfterStop(t *testing.T) {
+	tp := newPool(t, WithWorkers(1))  // not started, thus the job of the first tick stays queued.
+
+	id, err := tp.AddCron("@every 10ms", func() JobProcessor { return valueJob(nil) }, CronOptions{Overlap: OverlapSkip})
+	if err != nil {
+		t.Fatalf("AddCron(): %v", err)
+	}
+	waitFor(t, "ticks skipped", func() bool {
+		e, _ := tp.GetCronEntry(id)
+		return (e.Runs == 1) && (e.Skipped > 0)
+	})
+
+	inflight := func() int {
+		tp.cmu.Lock()
+		defer tp.cmu.Unlock()
+		return tp.crons[id].inflight
+	}
+	if n := inflight(); n != 1 {
+		t.Fatalf("%d jobs in flight, want 1", n)
+	}
+
+	tp.Stop()
+	waitFor(t, "in-flight job released once the worker-pool is stopped", func() bool {
+		return inflight() == 0
+	})
+}
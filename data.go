@@ -26,8 +26,16 @@ var newPoolID int32
 // updated using atomic.AddInt32().
 var workercnt int32
 
+// NewWorkerPool() clamps worker-pool size to [minWPSize, maxWPSize]. New() rather validates the
+// size, minWPSize is its default size.
 const minWPSize int32 = 10
 const maxWPSize int32 = 100
+
+// default size of the job-queue is jpwpfactor times the no. of workers.
 const jpwpfactor int32 = 100
 
+// channels allocated upfront - results channel and queue of the result processing stage - are
+// at most this size, whatever the job-queue capacity and the no. of workers.
+const maxChanSize int32 = 1 << 16
+
 const EMPTY_STRING string = ""
@@ -0,0 +1,508 @@
+/* *****************************************************************************
+Copyright (c) 2023, sameeroak1110 (sameeroak1110@gmail.com)
+BSD 3-Clause License.
+
+Package     : github.com/sameeroak1110/gowp
+Filename    : github.com/sameeroak1110/gowp/deadletter.go
+File-type   : GoLang source code file
+
+Compiler/Runtime: go version go1.20.5 linux/amd64
+
+Version History
+Version     : 1.0
+Author      : Sameer Oak (sameeroak1110@gmail.com)
+
+Description :
+- Dead-letter queue. Jobs that fail permanently - their retries, if any, are exhausted or they
+panic - are recorded in a DeadLetterSink instead of vanishing.
+- Dead-lettered jobs can be listed, inspected, and requeued into a worker-pool.
+- Two sinks are provided, in-memory and a file-backed one that writes JSON lines.
+***************************************************************************** */
+package gowp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// a dead-lettered job.
+type DeadLetter struct {
+	ID          uint64          `json:"id"`                // assigned by the sink.
+	JobID       uint64          `json:"job_id"`            // ID of the job in the worker-pool it failed in.
+	JobName     string          `json:"job_name"`
+	PoolName    string          `json:"pool_name"`
+	Payload     json.RawMessage `json:"payload,omitempty"` // JobProcessor encoded as JSON, if it's serializable.
+	Attempts    int             `json:"attempts"`
+	LastError   string          `json:"last_error"`
+	Panicked    bool            `json:"panicked"`
+	SubmittedAt time.Time       `json:"submitted_at"`
+	FailedAt    time.Time       `json:"failed_at"`
+	Priority    int             `json:"priority"`           // submit options of the job, reapplied once it's requeued.
+	Tenant      string          `json:"tenant,omitempty"`
+	Key         string          `json:"key,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+	job         JobProcessor    // the job itself, available as long as the dead letter is in memory.
+}
+
+// - DeadLetterSink records dead-lettered jobs.
+// - Implementations must be safe for concurrent use.
+type DeadLetterSink interface {
+	Put(DeadLetter) (uint64, error)  // records the dead letter and returns the ID assigned to it.
+	List() ([]DeadLetter, error)     // returns all the dead letters, oldest first.
+	Get(uint64) (DeadLetter, error)  // returns the dead letter by ID, ErrDeadLetterNotFound if there's none.
+	Remove(uint64) error             // removes the dead letter by ID, ErrDeadLetterNotFound if there's none.
+}
+
+// decodes a dead letter, that's no more in memory, back into a job. see WorkerPoolOptions.DeadLetterDecoder.
+type DeadLetterDecoder func(DeadLetter) (JobProcessor, error)
+
+var ErrNoDeadLetterSink = errors.New("gowp: worker-pool has no dead-letter sink")
+var ErrDeadLetterNotFound = errors.New("gowp: dead letter not found")
+var ErrDeadLetterNotDecodable = errors.New("gowp: dead letter can't be decoded into a job")
+
+
+// GetJob returns the dead-lettered job, nil unless the dead letter is in memory.
+func (dl DeadLetter) GetJob() JobProcessor {
+	return dl.job
+}
+
+
+// submit options the dead-lettered job was originally submitted with.
+func (dl DeadLetter) submitOptions() []SubmitOption {
+	opts := []SubmitOption{WithPriority(dl.Priority)}
+	if dl.Tenant != "" {
+		opts = append(opts, WithTenant(dl.Tenant))
+	}
+	if dl.Key != "" {
+		opts = append(opts, WithKey(dl.Key))
+	}
+	if dl.Metadata != nil {
+		opts = append(opts, WithMetadata(dl.Metadata))
+	}
+
+	return opts
+}
+
+
+/* *****************************************************************************
+Description : Records a permanently failed job in the dead-letter sink.
+
+Receiver    : *WorkerPool
+
+Implements  : NA
+
+Arguments   :
+1> job Job: Failed job.
+2> js JobStatus: Execution status of its last attempt.
+
+Return value: NA
+
+Additional note:
+- Jobs that fail because the worker-pool context is cancelled, or that are cancelled through
+Cancel(), aren't dead-lettered.
+- If the sink fails to record the job, the error is reported to
+WorkerPoolOptions.OnDeadLetterError, if set.
+***************************************************************************** */
+func (pwp *WorkerPool) deadLetter(job Job, js JobStatus) {
+	if (pwp.dlsink == nil) || (js.err == nil) || (pwp.GetContext().Err() != nil) || errors.Is(js.err, ErrJobCancelled) {
+		return
+	}
+
+	var pe *PanicError
+	dl := DeadLetter {
+		JobID: job.id,
+		JobName: job.name,
+		PoolName: pwp.name,
+		Attempts: js.attempts,
+		LastError: js.err.Error(),
+		Panicked: errors.As(js.err, &pe),
+		SubmittedAt: job.submittedAt,
+		FailedAt: time.Now(),
+		Priority: job.priority,
+		Tenant: job.tenant,
+		Key: job.key,
+		Metadata: job.metadata,
+		job: job.data,
+	}
+
+	if payload, err := json.Marshal(unwrapJob(job.data)); err == nil {
+		dl.Payload = payload
+	}
+
+	if _, err := pwp.dlsink.Put(dl); (err != nil) && (pwp.onDeadLetterError != nil) {
+		pwp.onDeadLetterError(job, err)
+	}
+
+	return
+}
+
+
+// DeadLetters returns the jobs dead-lettered in the dead-letter sink of the worker-pool.
+func (pwp *WorkerPool) DeadLetters() ([]DeadLetter, error) {
+	if pwp.dlsink == nil {
+		return nil, ErrNoDeadLetterSink
+	}
+
+	return pwp.dlsink.List()
+}
+
+
+// DeadLetter returns the dead letter by ID from the dead-letter sink of the worker-pool.
+func (pwp *WorkerPool) DeadLetter(id uint64) (DeadLetter, error) {
+	if pwp.dlsink == nil {
+		return DeadLetter{}, ErrNoDeadLetterSink
+	}
+
+	return pwp.dlsink.Get(id)
+}
+
+
+/* *****************************************************************************
+Description : Submits a dead-lettered job to the worker-pool again.
+
+Receiver    : *WorkerPool
+
+Implements  : NA
+
+Arguments   :
+1> ctx context.Context: Bounds the wait for room in the job queue.
+2> id uint64: ID of the dead letter.
+
+Return value:
+1> *JobHandle: Future of the requeued job.
+2> error: ErrNoDeadLetterSink, ErrDeadLetterNotFound, ErrDeadLetterNotDecodable - wrapping the
+decoder's error, if any - or the error returned by Submit().
+
+Additional note:
+- The dead letter is removed from the sink once the job is requeued.
+- The job is submitted with the priority, tenant, key, and metadata it was originally submitted
+with. Its timeout comes along with the job, see Timeouter, or is the worker-pool default.
+- If the dead letter isn't in memory anymore - for instance, it's read back from a file - it's
+decoded into a job using WorkerPoolOptions.DeadLetterDecoder.
+***************************************************************************** */
+func (pwp *WorkerPool) RequeueDeadLetter(ctx context.Context, id uint64) (*JobHandle, error) {
+	if pwp.dlsink == nil {
+		return nil, ErrNoDeadLetterSink
+	}
+
+	dl, err := pwp.dlsink.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	job := dl.job
+	if job == nil {
+		if pwp.dldecoder == nil {
+			return nil, ErrDeadLetterNotDecodable
+		}
+
+		if job, err = pwp.dldecoder(dl); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrDeadLetterNotDecodable, err)
+		}
+	}
+
+	ph, err := pwp.SubmitWithHandle(ctx, job, dl.submitOptions()...)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := pwp.dlsink.Remove(id); (err != nil) && !errors.Is(err, ErrDeadLetterNotFound) {
+		return ph, err
+	}
+
+	return ph, nil
+}
+
+
+// in-memory dead-letter sink.
+type MemoryDeadLetterSink struct {
+	mu sync.Mutex
+	nextID uint64
+	dls []DeadLetter
+}
+
+
+func NewMemoryDeadLetterSink() *MemoryDeadLetterSink {
+	return &MemoryDeadLetterSink{}
+}
+
+
+func (s *MemoryDeadLetterSink) Put(dl DeadLetter) (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextID++
+	dl.ID = s.nextID
+	s.dls = append(s.dls, dl)
+
+	return dl.ID, nil
+}
+
+
+func (s *MemoryDeadLetterSink) List() ([]DeadLetter, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dls := make([]DeadLetter, len(s.dls))
+	copy(dls, s.dls)
+
+	return dls, nil
+}
+
+
+func (s *MemoryDeadLetterSink) Get(id uint64) (DeadLetter, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, dl := range s.dls {
+		if dl.ID == id {
+			return dl, nil
+		}
+	}
+
+	return DeadLetter{}, ErrDeadLetterNotFound
+}
+
+
+func (s *MemoryDeadLetterSink) Remove(id uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, dl := range s.dls {
+		if dl.ID == id {
+			s.dls = append(s.dls[:i], s.dls[i+1:]...)
+			return nil
+		}
+	}
+
+	return ErrDeadLetterNotFound
+}
+
+
+// - File-backed dead-letter sink. Each dead letter is a line of JSON (JSONL) in the file.
+// - Jobs dead-lettered since the sink was opened are kept in memory as well, so they can be
+// requeued without decoding.
+type FileDeadLetterSink struct {
+	mu sync.Mutex
+	path string
+	fp *os.File
+	nextID uint64
+	jobs map[uint64]JobProcessor
+}
+
+
+/* *****************************************************************************
+Description : Opens a file-backed dead-letter sink. The file is created if it doesn't exist.
+
+Arguments   :
+1> path string: Path of the JSONL file.
+
+Return value:
+1> *FileDeadLetterSink: Newly opened sink.
+2> error: Error in case the file can't be opened or has an invalid line.
+
+Additional note:
+IDs of the new dead letters follow the ones already in the file.
+***************************************************************************** */
+func NewFileDeadLetterSink(path string) (*FileDeadLetterSink, error) {
+	s := &FileDeadLetterSink {
+		path: path,
+		jobs: make(map[uint64]JobProcessor),
+	}
+
+	dls, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	for _, dl := range dls {
+		if dl.ID > s.nextID {
+			s.nextID = dl.ID
+		}
+	}
+
+	if s.fp, err = os.OpenFile(path, os.O_CREATE | os.O_APPEND | os.O_WRONLY, 0644); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+
+// reads all the dead letters from the file. mu must be held, except while opening the sink.
+func (s *FileDeadLetterSink) read() ([]DeadLetter, error) {
+	fp, err := os.Open(s.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer fp.Close()
+
+	var dls []DeadLetter
+	scanner := bufio.NewScanner(fp)
+	scanner.Buffer(make([]byte, 64 * 1024), 16 * 1024 * 1024)
+	for lineno := 1; scanner.Scan(); lineno++ {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		var dl DeadLetter
+		if err := json.Unmarshal(line, &dl); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", s.path, lineno, err)
+		}
+		dl.job = s.jobs[dl.ID]
+		dls = append(dls, dl)
+	}
+
+	return dls, scanner.Err()
+}
+
+
+func (s *FileDeadLetterSink) Put(dl DeadLetter) (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.fp == nil {
+		return 0, os.ErrClosed
+	}
+
+	dl.ID = s.nextID + 1
+	line, err := json.Marshal(dl)
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := s.fp.Write(append(line, '\n')); err != nil {
+		return 0, err
+	}
+
+	s.nextID = dl.ID
+	if dl.job != nil {
+		s.jobs[dl.ID] = dl.job
+	}
+
+	return dl.ID, nil
+}
+
+
+func (s *FileDeadLetterSink) List() ([]DeadLetter, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.read()
+}
+
+
+func (s *FileDeadLetterSink) Get(id uint64) (DeadLetter, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dls, err := s.read()
+	if err != nil {
+		return DeadLetter{}, err
+	}
+
+	for _, dl := range dls {
+		if dl.ID == id {
+			return dl, nil
+		}
+	}
+
+	return DeadLetter{}, ErrDeadLetterNotFound
+}
+
+
+// Remove rewrites the file without the dead letter. The file keeps its mode.
+func (s *FileDeadLetterSink) Remove(id uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.fp == nil {
+		return os.ErrClosed
+	}
+
+	dls, err := s.read()
+	if err != nil {
+		return err
+	}
+
+	// the rewritten file keeps the mode of the original, rather than the mode of a temp file.
+	fi, err := os.Stat(s.path)
+	if err != nil {
+		return err
+	}
+
+	found := false
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path) + ".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())  // no-op once renamed.
+
+	w := bufio.NewWriter(tmp)
+	for _, dl := range dls {
+		if dl.ID == id {
+			found = true
+			continue
+		}
+
+		line, err := json.Marshal(dl)
+		if err != nil {
+			tmp.Close()
+			return err
+		}
+		w.Write(append(line, '\n'))
+	}
+
+	if !found {
+		tmp.Close()
+		return ErrDeadLetterNotFound
+	}
+
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(fi.Mode().Perm()); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	s.fp.Close()
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		s.fp, _ = os.OpenFile(s.path, os.O_CREATE | os.O_APPEND | os.O_WRONLY, 0644)
+		return err
+	}
+	delete(s.jobs, id)
+
+	s.fp, err = os.OpenFile(s.path, os.O_CREATE | os.O_APPEND | os.O_WRONLY, 0644)
+	return err
+}
+
+
+// Close closes the file. The sink isn't usable thereafter.
+func (s *FileDeadLetterSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.fp == nil {
+		return nil
+	}
+
+	err := s.fp.Close()
+	s.fp = nil
+
+	return err
+}
@@ -0,0 +1,277 @@
+package gowp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+// serializable job that fails its first n runs.
+type flakyJob struct {
+	N int `json:"n"`
+	fails *int32
+}
+
+func (j flakyJob) GetName() string {
+	return "flaky"
+}
+
+func (j flakyJob) Process(ctx context.Context, ctl JobControl) (interface{}, error) {
+	if atomic.AddInt32(j.fails, -1) >= 0 {
+		return nil, errTest
+	}
+	v, _ := ctl.Metadata("m")
+	return v, nil
+}
+
+
+// sink that fails to record any dead letter.
+type brokenSink struct {
+	MemoryDeadLetterSink
+}
+
+func (s *brokenSink) Put(DeadLetter) (uint64, error) {
+	return 0, errTest
+}
+
+
+func TestDeadLetterWhich(t *testing.T) {
+	tests := []struct {
+		name string
+		job JobProcessor
+		retry *RetryPolicy
+		letters int
+		attempts int
+		panicked bool
+	}{
+		{"succeeded", valueJob(1), nil, 0, 0, false},
+		{"failed", errJob(errTest), nil, 1, 1, false},
+		{"retries exhausted", errJob(errTest), &RetryPolicy{MaxAttempts: 3}, 1, 3, false},
+		{"panicked", Func("panic", func(context.Context) (interface{}, error) { panic("boom") }), &RetryPolicy{MaxAttempts: 3}, 1, 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sink := NewMemoryDeadLetterSink()
+			tp := startPool(t, WithWorkers(1), WithRetryPolicy(tt.retry), WithDeadLetterSink(sink, nil))
+
+			h, err := tp.SubmitWithHandle(context.Background(), tt.job)
+			if err != nil {
+				t.Fatalf("SubmitWithHandle(): %v", err)
+			}
+			wait(t, h)
+
+			dls, _ := tp.DeadLetters()
+			if len(dls) != tt.letters {
+				t.Fatalf("%d dead letters, want %d", len(dls), tt.letters)
+			}
+			if tt.letters == 0 {
+				return
+			}
+			if (dls[0].JobID != h.GetID()) || (dls[0].Attempts != tt.attempts) || (dls[0].Panicked != tt.panicked) {
+				t.Fatalf("dead letter %+v, want job %d, %d attempts, panicked %t", dls[0], h.GetID(), tt.attempts, tt.panicked)
+			}
+		})
+	}
+}
+
+
+func TestDeadLetterCancelled(t *testing.T) {
+	sink := NewMemoryDeadLetterSink()
+	tp := startPool(t, WithWorkers(1), WithDeadLetterSink(sink, nil))
+
+	started := make(chan struct{})
+	h, err := tp.SubmitWithHandle(context.Background(), blockJob(started, nil))
+	if err != nil {
+		t.Fatalf("SubmitWithHandle(): %v", err)
+	}
+	<-started
+	tp.Cancel(h.GetID())
+
+	if _, err := wait(t, h); !errors.Is(err, ErrJobCancelled) {
+		t.Fatalf("job: %v, want ErrJobCancelled", err)
+	}
+	if dls, _ := tp.DeadLetters(); len(dls) != 0 {
+		t.Fatalf("%d dead letters of a cancelled job", len(dls))
+	}
+}
+
+
+func TestRequeueDeadLetter(t *testing.T) {
+	fails := int32(1)
+	sink := NewMemoryDeadLetterSink()
+	tp := startPool(t, WithWorkers(1), WithDeadLetterSink(sink, nil))
+
+	h, err := tp.SubmitWithHandle(context.Background(), V2(flakyJob{N: 1, fails: &fails}),
+		WithPriority(7), WithTenant("t1"), WithKey("k1"), WithMetadata(map[string]string{"m": "v"}))
+	if err != nil {
+		t.Fatalf("SubmitWithHandle(): %v", err)
+	}
+	wait(t, h)
+
+	dls, _ := tp.DeadLetters()
+	if len(dls) != 1 {
+		t.Fatalf("%d dead letters, want 1", len(dls))
+	}
+	dl := dls[0]
+	if (dl.Priority != 7) || (dl.Tenant != "t1") || (dl.Key != "k1") || (dl.Metadata["m"] != "v") {
+		t.Fatalf("dead letter %+v doesn't carry the submit options", dl)
+	}
+
+	rh, err := tp.RequeueDeadLetter(context.Background(), dl.ID)
+	if err != nil {
+		t.Fatalf("RequeueDeadLetter(): %v", err)
+	}
+	if v, err := wait(t, rh); (err != nil) || (v != "v") {
+		t.Fatalf("requeued job: %v, %v", v, err)
+	}
+
+	ji, _ := tp.JobInfo(rh.GetID())
+	if (ji.Tenant != "t1") || (ji.Key != "k1") || (ji.Metadata["m"] != "v") {
+		t.Fatalf("requeued job %+v lost its submit options", ji)
+	}
+	if _, err := sink.Get(dl.ID); !errors.Is(err, ErrDeadLetterNotFound) {
+		t.Fatalf("dead letter still in the sink: %v", err)
+	}
+	if _, err := tp.RequeueDeadLetter(context.Background(), dl.ID); !errors.Is(err, ErrDeadLetterNotFound) {
+		t.Fatalf("RequeueDeadLetter() again: %v, want ErrDeadLetterNotFound", err)
+	}
+}
+
+
+func TestDeadLetterSinkError(t *testing.T) {
+	reported := make(chan error, 1)
+	tp := startPool(t, WithWorkers(1), WithDeadLetterSink(&brokenSink{}, nil), WithOnDeadLetterError(func(_ Job, err error) {
+		reported <- err
+	}))
+
+	h, err := tp.SubmitWithHandle(context.Background(), errJob(errTest))
+	if err != nil {
+		t.Fatalf("SubmitWithHandle(): %v", err)
+	}
+	wait(t, h)
+
+	if err := <-reported; err != errTest {
+		t.Fatalf("OnDeadLetterError(): %v, want the sink's error", err)
+	}
+}
+
+
+func TestFileDeadLetterSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dl.jsonl")
+
+	fails := int32(1)
+	sink, err := NewFileDeadLetterSink(path)
+	if err != nil {
+		t.Fatalf("NewFileDeadLetterSink(): %v", err)
+	}
+	tp := startPool(t, WithWorkers(1), WithDeadLetterSink(sink, nil))
+	h, _ := tp.SubmitWithHandle(context.Background(), V2(flakyJob{N: 3, fails: &fails}), WithKey("k1"))
+	wait(t, h)
+	sink.Close()
+
+	// read back by a new sink, the job is decoded from its payload then.
+	sink, err = NewFileDeadLetterSink(path)
+	if err != nil {
+		t.Fatalf("NewFileDeadLetterSink(): %v", err)
+	}
+	defer sink.Close()
+	decoder := func(dl DeadLetter) (JobProcessor, error) {
+		var j flakyJob
+		if err := json.Unmarshal(dl.Payload, &j); err != nil {
+			return nil, err
+		}
+		j.fails = new(int32)
+		return V2(j), nil
+	}
+	tp2 := startPool(t, WithWorkers(1), WithDeadLetterSink(sink, decoder))
+
+	dls, _ := tp2.DeadLetters()
+	if (len(dls) != 1) || (dls[0].Key != "k1") || (dls[0].GetJob() != nil) {
+		t.Fatalf("dead letters read back %+v", dls)
+	}
+	rh, err := tp2.RequeueDeadLetter(context.Background(), dls[0].ID)
+	if err != nil {
+		t.Fatalf("RequeueDeadLetter(): %v", err)
+	}
+	if _, err := wait(t, rh); err != nil {
+		t.Fatalf("requeued job: %v", err)
+	}
+
+	// an invalid line is reported along with the JSON error.
+	if err := os.WriteFile(path, []byte("{not json\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var se *json.SyntaxError
+	if _, err := NewFileDeadLetterSink(path); !errors.As(err, &se) {
+		t.Fatalf("NewFileDeadLetterSink() of an invalid file: %v, want a *json.SyntaxError", err)
+	}
+}
+
+
+func TestRequeueNotDecodable(t *testing.T) {
+	sink := NewMemoryDeadLetterSink()
+	id, err := sink.Put(DeadLetter{JobName: "decoded"})  // as if read back, there's no job along.
+	if err != nil {
+		t.Fatalf("Put(): %v", err)
+	}
+
+	tests := []struct {
+		name string
+		decoder DeadLetterDecoder
+		wantIs []error
+	}{
+		{"no decoder", nil, []error{ErrDeadLetterNotDecodable}},
+		{"decoder fails", func(DeadLetter) (JobProcessor, error) { return nil, errTest }, []error{ErrDeadLetterNotDecodable, errTest}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tp := startPool(t, WithWorkers(1), WithDeadLetterSink(sink, tt.decoder))
+
+			_, err := tp.RequeueDeadLetter(context.Background(), id)
+			for _, target := range tt.wantIs {
+				if !errors.Is(err, target) {
+					t.Fatalf("RequeueDeadLetter(): %v, want it to wrap %v", err, target)
+				}
+			}
+		})
+	}
+}
+
+
+func TestFileDeadLetterSinkRemoveKeepsMode(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dl.jsonl")
+	if err := os.WriteFile(path, nil, 0o640); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(path, 0o640); err != nil {  // regardless of umask.
+		t.Fatal(err)
+	}
+
+	sink, err := NewFileDeadLetterSink(path)
+	if err != nil {
+		t.Fatalf("NewFileDeadLetterSink(): %v", err)
+	}
+	defer sink.Close()
+	id, _ := sink.Put(DeadLetter{JobName: "first"})
+	sink.Put(DeadLetter{JobName: "second"})
+
+	if err := sink.Remove(id); err != nil {
+		t.Fatalf("Remove(): %v", err)
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := fi.Mode().Perm(); mode != 0o640 {
+		t.Fatalf("file mode %o once rewritten, want 640", mode)
+	}
+	if dls, _ := sink.List(); (len(dls) != 1) || (dls[0].JobName != "second") {
+		t.Fatalf("dead letters %+v once one is removed", dls)
+	}
+}
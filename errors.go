@@ -0,0 +1,57 @@
+/* *****************************************************************************
+Copyright (c) 2023, sameeroak1110 (sameeroak1110@gmail.com)
+BSD 3-Clause License.
+
+Package     : github.com/sameeroak1110/gowp
+Filename    : github.com/sameeroak1110/gowp/errors.go
+File-type   : GoLang source code file
+
+Compiler/Runtime: go version go1.20.5 linux/amd64
+
+Version History
+Version     : 1.0
+Author      : Sameer Oak (sameeroak1110@gmail.com)
+
+Description :
+- Errors returned by the worker-pool. Compare using errors.Is().
+***************************************************************************** */
+package gowp
+
+import (
+	"errors"
+	"fmt"
+)
+
+// job queue is full and the job couldn't be added without waiting.
+var ErrQueueFull = errors.New("gowp: job queue is full")
+
+// worker-pool has been stopped and isn't accepting jobs.
+var ErrPoolStopped = errors.New("gowp: worker-pool is stopped")
+
+// worker-pool is neither running nor paused.
+var ErrPoolNotRunning = errors.New("gowp: worker-pool isn't running")
+
+
+// - PanicError is the job error if the job panics while it's being executed.
+// - It carries the value passed to panic() and the stack trace of the job go-routine.
+type PanicError struct {
+	Value interface{}  // value passed to panic().
+	Stack []byte       // stack trace of the go-routine at the time of panic.
+	JobID uint64       // ID of the job that panicked.
+	JobName string     // name of the job that panicked.
+}
+
+
+func (pe *PanicError) Error() string {
+	return fmt.Sprintf("gowp: job %s:%d panicked: %v", pe.JobName, pe.JobID, pe.Value)
+}
+
+
+// Unwrap returns the value passed to panic() if it's an error.
+func (pe *PanicError) Unwrap() error {
+	if err, ok := pe.Value.(error); ok {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,355 @@
+/* *****************************************************************************
+Copyright (c) 2023, sameeroak1110 (sameeroak1110@gmail.com)
+BSD 3-Clause License.
+
+Package     : github.com/sameeroak1110/gowp
+Filename    : github.com/sameeroak1110/gowp/fair.go
+File-type   : GoLang source code file
+
+Compiler/Runtime: go version go1.20.5 linux/amd64
+
+Version History
+Version     : 1.0
+Author      : Sameer Oak (sameeroak1110@gmail.com)
+
+Description :
+- Fair job queue, enabled through WithFairQueue().
+- Each tenant has a FIFO queue of its own. Tenants with queued jobs are served in deficit round
+robin order. Since each job costs one, a tenant of weight w gets w jobs popped in its turn.
+- A tenant's queue may be limited, so one tenant can't fill the whole job queue.
+***************************************************************************** */
+package gowp
+
+import (
+	"fmt"
+	"time"
+)
+
+var ErrTenantQueueFull = fmt.Errorf("%w: tenant queue is full", ErrQueueFull)
+
+// Fair queue configuration, see WithFairQueue().
+type FairQueueOptions struct {
+	Weights       map[string]int  // weight of each tenant. default is DefaultWeight.
+	DefaultWeight int             // weight of the tenants not in Weights. default is 1.
+	Limits        map[string]int  // maximum no. of queued jobs of each tenant. default is DefaultLimit.
+	DefaultLimit  int             // limit of the tenants not in Limits. 0 means no limit.
+}
+
+// statistics of a tenant.
+type TenantStats struct {
+	Weight     int           // weight of the tenant.
+	Submitted  uint64        // no. of jobs of the tenant pushed to the queue.
+	Dispatched uint64        // no. of jobs of the tenant popped from the queue.
+	Queued     int           // no. of jobs of the tenant in the queue right now.
+	AvgWait    time.Duration // average queue wait of the popped jobs.
+	MaxWait    time.Duration // maximum queue wait of the popped jobs.
+}
+
+// queue of a tenant.
+type tenantQueue struct {
+	key string
+	jobs fifoQueue
+	deficit int          // no. of jobs still to be popped in the current turn.
+	active bool          // true if the tenant is in the round robin.
+	waitsum time.Duration
+	stats TenantStats
+}
+
+// fair job queue.
+type fairQueue struct {
+	opts FairQueueOptions
+	tenants map[string]*tenantQueue
+	ring []*tenantQueue   // tenants with queued jobs, in round robin order.
+	cur int               // index of the tenant whose turn it is.
+	n int                 // total no. of queued jobs.
+}
+
+
+// validates the fair queue configuration.
+func (opts *FairQueueOptions) validate() error {
+	if opts.DefaultWeight < 0 {
+		return fmt.Errorf("%w: default tenant weight %d, must not be negative", ErrInvalidOption, opts.DefaultWeight)
+	}
+
+	if opts.DefaultLimit < 0 {
+		return fmt.Errorf("%w: default tenant limit %d, must not be negative", ErrInvalidOption, opts.DefaultLimit)
+	}
+
+	for key, w := range opts.Weights {
+		if w < 1 {
+			return fmt.Errorf("%w: weight %d of tenant %q, must be at least 1", ErrInvalidOption, w, key)
+		}
+	}
+
+	for key, l := range opts.Limits {
+		if l < 0 {
+			return fmt.Errorf("%w: limit %d of tenant %q, must not be negative", ErrInvalidOption, l, key)
+		}
+	}
+
+	return nil
+}
+
+
+// creates a fair queue. the options are copied.
+func newFairQueue(opts FairQueueOptions) *fairQueue {
+	q := &fairQueue {
+		opts: FairQueueOptions {
+			Weights: make(map[string]int),
+			DefaultWeight: opts.DefaultWeight,
+			Limits: make(map[string]int),
+			DefaultLimit: opts.DefaultLimit,
+		},
+		tenants: make(map[string]*tenantQueue),
+	}
+
+	if q.opts.DefaultWeight == 0 {
+		q.opts.DefaultWeight = 1
+	}
+	for key, w := range opts.Weights {
+		q.opts.Weights[key] = w
+	}
+	for key, l := range opts.Limits {
+		q.opts.Limits[key] = l
+	}
+
+	return q
+}
+
+
+func (q *fairQueue) weightOf(key string) int {
+	if w, ok := q.opts.Weights[key]; ok {
+		return w
+	}
+
+	return q.opts.DefaultWeight
+}
+
+
+func (q *fairQueue) limitOf(key string) int {
+	if l, ok := q.opts.Limits[key]; ok {
+		return l
+	}
+
+	return q.opts.DefaultLimit
+}
+
+
+func (q *fairQueue) tenantOf(key string) *tenantQueue {
+	t, ok := q.tenants[key]
+	if !ok {
+		t = &tenantQueue{key: key}
+		q.tenants[key] = t
+	}
+
+	return t
+}
+
+
+// true if the job's tenant has as many queued jobs as its limit.
+func (q *fairQueue) full(j Job) bool {
+	l := q.limitOf(j.tenant)
+	if l == 0 {
+		return false
+	}
+
+	t, ok := q.tenants[j.tenant]
+	return ok && (t.jobs.len() >= l)
+}
+
+
+func (q *fairQueue) push(j Job) {
+	t := q.tenantOf(j.tenant)
+	t.jobs.push(j)
+	t.stats.Submitted++
+	q.n++
+
+	if !t.active {
+		t.active = true
+		t.deficit = 0
+		q.ring = append(q.ring, t)
+	}
+}
+
+
+func (q *fairQueue) pop() (Job, bool) {
+	if len(q.ring) == 0 {
+		return Job{}, false
+	}
+
+	if q.cur >= len(q.ring) {
+		q.cur = 0
+	}
+
+	t := q.ring[q.cur]
+	if t.deficit <= 0 {
+		t.deficit = q.weightOf(t.key)  // tenant's turn begins.
+	}
+
+	j, _ := t.jobs.pop()  // never fails, tenants in the ring have queued jobs.
+	t.deficit--
+	q.n--
+
+	wait := time.Since(j.submittedAt)
+	t.stats.Dispatched++
+	t.waitsum += wait
+	if wait > t.stats.MaxWait {
+		t.stats.MaxWait = wait
+	}
+
+	if t.jobs.len() == 0 {
+		// tenant leaves the round robin, the next one takes its place at cur.
+		t.active = false
+		t.deficit = 0
+		copy(q.ring[q.cur:], q.ring[q.cur+1:])
+		q.ring[len(q.ring)-1] = nil
+		q.ring = q.ring[:len(q.ring)-1]
+	} else if t.deficit <= 0 {
+		q.cur++  // tenant's turn is over.
+	}
+
+	return j, true
+}
+
+
+// returns the oldest queued job.
+func (q *fairQueue) peek() (Job, bool) {
+	var oldest Job
+	found := false
+	for _, t := range q.ring {
+		j, _ := t.jobs.peek()
+		if !found || j.submittedAt.Before(oldest.submittedAt) {
+			oldest = j
+			found = true
+		}
+	}
+
+	return oldest, found
+}
+
+
+func (q *fairQueue) len() int {
+	return q.n
+}
+
+
+// removes the jobs for which pred is true. they aren't counted as dispatched. tenants left with
+// no queued jobs leave the round robin.
+func (q *fairQueue) removeWhere(pred func(Job) bool) []Job {
+	var removed []Job
+	n := 0
+	for i, t := range q.ring {
+		rm := t.jobs.removeWhere(pred)
+		removed = append(removed, rm...)
+		q.n -= len(rm)
+
+		if t.jobs.len() > 0 {
+			q.ring[n] = t
+			n++
+			continue
+		}
+
+		t.active = false
+		t.deficit = 0
+		if i < q.cur {
+			q.cur--  // cur keeps pointing to the same tenant.
+		}
+	}
+
+	for i := n; i < len(q.ring); i++ {
+		q.ring[i] = nil
+	}
+	q.ring = q.ring[:n]
+
+	return removed
+}
+
+
+// WithFairQueue makes the job queue a fair queue across the tenants, see WithTenant().
+func WithFairQueue(opts FairQueueOptions) Option {
+	return func(cfg *poolConfig) error {
+		if err := opts.validate(); err != nil {
+			return err
+		}
+		cfg.fair = &opts
+		return nil
+	}
+}
+
+
+// WithTenant sets the tenant of the job. Default is the empty tenant.
+func WithTenant(key string) SubmitOption {
+	return func(sc *submitConfig) {
+		sc.tenant = key
+	}
+}
+
+
+/* *****************************************************************************
+Description : Returns statistics of each tenant.
+
+Receiver    : *WorkerPool
+
+Implements  : NA
+
+Arguments   : NA
+
+Return value:
+1> map[string]TenantStats: Statistics by tenant. Empty unless the worker-pool has a fair queue.
+
+Additional note: NA
+***************************************************************************** */
+func (pwp *WorkerPool) TenantStats() map[string]TenantStats {
+	pwp.qmu.Lock()
+	defer pwp.qmu.Unlock()
+
+	stats := make(map[string]TenantStats)
+	if q, ok := pwp.jobq.(*fairQueue); ok {
+		for key, t := range q.tenants {
+			ts := t.stats
+			ts.Weight = q.weightOf(key)
+			ts.Queued = t.jobs.len()
+			if ts.Dispatched > 0 {
+				ts.AvgWait = t.waitsum / time.Duration(ts.Dispatched)
+			}
+			stats[key] = ts
+		}
+	}
+
+	return stats
+}
+
+
+/* *****************************************************************************
+Description : Changes the weight of a tenant.
+
+Receiver    : *WorkerPool
+
+Implements  : NA
+
+Arguments   :
+1> key string: Tenant.
+2> weight int: New weight, at least 1.
+
+Return value:
+1> error: ErrInvalidOption if weight is less than 1 or the worker-pool doesn't have a fair queue.
+
+Additional note:
+Takes effect from the tenant's next turn.
+***************************************************************************** */
+func (pwp *WorkerPool) SetTenantWeight(key string, weight int) error {
+	if weight < 1 {
+		return fmt.Errorf("%w: weight %d of tenant %q, must be at least 1", ErrInvalidOption, weight, key)
+	}
+
+	pwp.qmu.Lock()
+	defer pwp.qmu.Unlock()
+
+	q, ok := pwp.jobq.(*fairQueue)
+	if !ok {
+		return fmt.Errorf("%w: worker-pool doesn't have a fair queue", ErrInvalidOption)
+	}
+	q.opts.Weights[key] = weight
+
+	return nil
+}
@@ -0,0 +1,134 @@
+package gowp
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// pushes the jobs, one tenant per letter, and pops them all. returns the tenants in pop order.
+func drainFair(q *fairQueue, tenants string) string {
+	now := time.Now()
+	for i, key := range tenants {
+		q.push(Job{id: uint64(i + 1), tenant: string(key), submittedAt: now})
+	}
+
+	var b strings.Builder
+	for {
+		j, ok := q.pop()
+		if !ok {
+			return b.String()
+		}
+		b.WriteString(j.tenant)
+	}
+}
+
+
+func TestFairQueueOrder(t *testing.T) {
+	tests := []struct {
+		name string
+		opts FairQueueOptions
+		pushed string  // tenant of each job, in push order.
+		want string    // tenant of each job, in pop order.
+	}{
+		{"single tenant is FIFO", FairQueueOptions{}, "aaa", "aaa"},
+		{"equal weights alternate", FairQueueOptions{}, "aaaabb", "ababaa"},
+		{"noisy tenant doesn't hold up the others", FairQueueOptions{}, "aaaaaabc", "abcaaaaa"},
+		{"weights", FairQueueOptions{Weights: map[string]int{"a": 3}}, "aaaaaabbb", "aaabaaabb"},
+		{"default weight", FairQueueOptions{DefaultWeight: 2, Weights: map[string]int{"b": 1}}, "aaaabbbb", "aabaabbb"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := newFairQueue(tt.opts)
+			if got := drainFair(q, tt.pushed); got != tt.want {
+				t.Fatalf("popped %q, want %q", got, tt.want)
+			}
+			if q.len() != 0 {
+				t.Fatalf("len() = %d once drained", q.len())
+			}
+		})
+	}
+}
+
+
+func TestFairQueueRemove(t *testing.T) {
+	q := newFairQueue(FairQueueOptions{})
+	now := time.Now()
+	for i, key := range "abcabc" {
+		q.push(Job{id: uint64(i + 1), tenant: string(key), submittedAt: now})
+	}
+	if j, _ := q.pop(); j.tenant != "a" {
+		t.Fatalf("popped tenant %q, want a", j.tenant)
+	}
+
+	// tenant b leaves the round robin, c's turn is next.
+	if rm := q.removeWhere(func(j Job) bool { return j.tenant == "b" }); len(rm) != 2 {
+		t.Fatalf("removed %d jobs, want 2", len(rm))
+	}
+	var got string
+	for {
+		j, ok := q.pop()
+		if !ok {
+			break
+		}
+		got += j.tenant
+	}
+	if got != "cac" {
+		t.Fatalf("popped %q after removal, want %q", got, "cac")
+	}
+}
+
+
+func TestFairQueueLimits(t *testing.T) {
+	q := newFairQueue(FairQueueOptions{DefaultLimit: 2, Limits: map[string]int{"a": 1, "b": 0}})
+	tests := []struct {
+		tenant string
+		full bool
+	}{
+		{"a", false}, {"a", true},
+		{"b", false}, {"b", false}, {"b", false},
+		{"c", false}, {"c", false}, {"c", true},
+	}
+
+	for i, tt := range tests {
+		j := Job{id: uint64(i + 1), tenant: tt.tenant}
+		if full := q.full(j); full != tt.full {
+			t.Fatalf("job %d of tenant %s: full() = %t, want %t", i, tt.tenant, full, tt.full)
+		}
+		if !tt.full {
+			q.push(j)
+		}
+	}
+}
+
+
+func TestTenantStats(t *testing.T) {
+	tp := newPool(t, WithWorkers(1), WithFairQueue(FairQueueOptions{Weights: map[string]int{"a": 2}}))
+	for _, key := range []string{"a", "a", "b"} {
+		if err := tp.TrySubmit(valueJob(nil), WithTenant(key)); err != nil {
+			t.Fatalf("TrySubmit(): %v", err)
+		}
+	}
+
+	ts := tp.TenantStats()
+	if (ts["a"].Weight != 2) || (ts["a"].Submitted != 2) || (ts["a"].Queued != 2) || (ts["b"].Weight != 1) {
+		t.Fatalf("TenantStats() %+v", ts)
+	}
+
+	if err := tp.SetTenantWeight("b", 4); err != nil {
+		t.Fatalf("SetTenantWeight(): %v", err)
+	}
+	if w := tp.TenantStats()["b"].Weight; w != 4 {
+		t.Fatalf("weight %d, want 4", w)
+	}
+	if err := tp.SetTenantWeight("b", 0); !errors.Is(err, ErrInvalidOption) {
+		t.Fatalf("SetTenantWeight(0): %v, want ErrInvalidOption", err)
+	}
+
+	plain := newPool(t, WithWorkers(1))
+	if err := plain.SetTenantWeight("b", 1); !errors.Is(err, ErrInvalidOption) {
+		t.Fatalf("SetTenantWeight() without a fair queue: %v, want ErrInvalidOption", err)
+	}
+}
@@ -1,10 +1,12 @@
 package gowp
 
 import (
+	"errors"
 	"fmt"
 	"math/rand"
 	"time"
 	"runtime"
+	"runtime/debug"
 	"context"
 	"sync"
 	"sync/atomic"
@@ -116,8 +118,10 @@ func (pwp *WorkerPool) GetMaxJobCnt() int {
 
 
 /* *****************************************************************************
-Description : Creates a new worker-pool instance. New worker-pool is created through this function.
-Size of job-queue is 100 times the number of workers (denoted by wpsize in the function call).
+Description : Creates a new worker-pool instance. Size of job-queue is 100 times the number of
+workers (denoted by wpsize in the function call).
+Kept for compatibility, New() with functional options is preferred. Unlike New(), this function
+clamps wpsize rather than returning an error.
 
 Receiver    : NA
 
@@ -127,15 +131,16 @@ Arguments   :
 1> tmpctx context.Context: Worker-pool context. This context is created in the upstream.
 2> cfunc context.CancelFunc: Cancel function of context.
 3> wpsize int32: Number of workers, denotes worker-pool size. Minimum size is 10 and maximum
-allowed size is 100.
-TODO: 4> isResponse bool: true if business logic needs job execution response.
-TODO: 5> jobctrl bool: context-timeout in the exec functions.
-6> opts WorkerPoolOptions: WorkerPool options. start-message, cancel-message, maxjobcnt, and shouldterminate flag.
+allowed size is 100. Ignored if opts.Autoscale is set, the worker-pool then starts with
+opts.Autoscale.MinWorkers workers.
+4> opts WorkerPoolOptions: WorkerPool options. maxjobcnt, shouldterminate flag, whether the
+business logic needs job execution response (IsResponse and ResultQSize), default job timeout
+(JobTimeout), and so on.
 
 Return value:
 1> *WorkerPool: Reference to the newly created worker-pool.
 2> int32: ID of newly created worker-pool.
-3> error: Only possible error is while creating a UUID.
+3> error: Error while creating a UUID, or ErrInvalidSize if opts.Autoscale is invalid.
 
 Additional note:
 wcnt, avlwcnt, and ID are book-keeping members. They're updated using atomic.AddInt32() function.
@@ -155,74 +160,406 @@ func NewWorkerPool(tmpctx context.Context, cfunc context.CancelFunc, wpsize int3
 		wpsize = maxWPSize
 	}
 
-	jpsize := wpsize * jpwpfactor
+	cfg := poolConfig {
+		cancelFunc: cfunc,
+		wpsize: wpsize,
+		name: _name,
+		smsg: smsg,
+		cmsg: cmsg,
+		opts: opts,
+	}
 
-	uuid, err := helper.NewUUID()
+	// autoscaler rather starts with the minimum no. of workers.
+	if opts.Autoscale != nil {
+		if err := opts.Autoscale.validate(); err != nil {
+			return nil, 0, err
+		}
+		cfg.wpsize = 0
+	}
+
+	pwp, err := newWorkerPool(tmpctx, cfg)
 	if err != nil {
 		return nil, 0, err
 	}
 
+	return pwp, pwp.id, nil
+}
+
+
+// creates a new worker-pool from a validated configuration. invoked by New() and NewWorkerPool().
+func newWorkerPool(tmpctx context.Context, cfg poolConfig) (*WorkerPool, error) {
+	opts := cfg.opts
+
+	wpsize := cfg.wpsize
+	if wpsize == 0 {
+		wpsize = minWPSize
+		if opts.Autoscale != nil {
+			wpsize = opts.Autoscale.MinWorkers
+		}
+	}
+
+	// job queue is sized for the maximum no. of workers the autoscaler may scale up to. sizes are
+	// computed as int, a large no. of workers times jpwpfactor overflows int32.
+	jpsize := int(wpsize) * int(jpwpfactor)
+	if opts.Autoscale != nil {
+		jpsize = int(opts.Autoscale.MaxWorkers) * int(jpwpfactor)
+	}
+
+	qcap := jpsize
+	if cfg.unbounded {
+		qcap = 0
+	} else if cfg.qcap > 0 {
+		qcap = cfg.qcap
+		jpsize = qcap
+	}
+
+	uuid, err := helper.NewUUID()
+	if err != nil {
+		return nil, err
+	}
+
+	var jobq jobQueue = &fifoQueue{}
+	if cfg.priority {
+		jobq = newPriorityQueue(cfg.aging)
+	} else if cfg.fair != nil {
+		jobq = newFairQueue(*cfg.fair)
+	}
+
 	wpID := atomic.AddInt32(&newPoolID, 1)
 	pwp := &WorkerPool {
 		id: wpID,
 		uuid: uuid,
-		jobq: make(chan Job, jpsize),
-		workers: make(chan int32, wpsize),
+		jobq: jobq,
+		kboxes: make(map[string]*fifoQueue),
+		live: make(map[uint64]Job),
+		sbyID: make(map[uint64]*scheduledJob),
+		swake: make(chan struct{}, 1),
+		crons: make(map[CronID]*cronEntry),
+		cwake: make(chan struct{}, 1),
+		qcap: qcap,
+		qnotify: make(chan struct{}, 1),
+		qspace: make(chan struct{}),
+		halted: make(chan struct{}),
 		ctx: tmpctx,
-		cancelFunc: cfunc,
+		cancelFunc: cfg.cancelFunc,
 		singletonCtrl: &sync.Mutex{},
+		onStateChange: opts.OnStateChange,
+		onPanic: opts.OnPanic,
+		onProgress: opts.OnProgress,
+		jobTimeout: opts.JobTimeout,
+		retryPolicy: opts.RetryPolicy,
+		dlsink: opts.DeadLetterSink,
+		dldecoder: opts.DeadLetterDecoder,
+		autoscale: opts.Autoscale,
 		wg: sync.WaitGroup{},
-		name: _name,
-		startMsg: smsg,
-		cancelMsg: cmsg,
+		name: cfg.name,
+		startMsg: cfg.smsg,
+		cancelMsg: cfg.cmsg,
 		maxJobCnt: opts.MaxJobCnt,
 		shouldTerminate: opts.ShouldTerminate,
+		terminate: append([]TerminationPolicy{}, opts.Terminate...),
+		tnotify: make(chan struct{}, 1),
+		isResponse: opts.IsResponse,
+		onResultError: opts.OnResultError,
+		onDeadLetterError: opts.OnDeadLetterError,
 	}
 
-	for i := int32(1); i <= wpsize; i++ {
-		pwp.workers <- i
+	hsize := opts.JobHistory
+	if hsize == 0 {
+		hsize = defaultJobHistory
 	}
-	pwp.avlwcnt = wpsize
+	pwp.history = newJobHistory(hsize, opts.JobHistoryMaxAge)
+	pwp.stats = newPoolStats()
 
-	return pwp, pwp.id, nil
+	if opts.ShouldTerminate && (opts.MaxJobCnt > 0) {
+		pwp.terminate = append(pwp.terminate, AfterCompleted(uint64(opts.MaxJobCnt)))
+	}
+
+	rwpsize := opts.ResultWorkers
+	if rwpsize <= 0 {
+		rwpsize = wpsize
+	}
+	pwp.rjobq = make(chan resultJob, chanSize(int(rwpsize) * int(jpwpfactor)))
+	pwp.rworkers = make(chan int32, rwpsize)
+	for i := int32(1); i <= rwpsize; i++ {
+		pwp.rworkers <- i
+	}
+
+	if pwp.isResponse {
+		rqsize := int(opts.ResultQSize)
+		if rqsize <= 0 {
+			rqsize = jpsize
+		}
+		pwp.resultq = make(chan JobStatus, chanSize(rqsize))  // NewWorkerPool() doesn't validate the size.
+	}
+
+	pwp.initWorkers(wpsize)
+
+	return pwp, nil
+}
+
+
+// caps the size of a channel allocated upfront at maxChanSize.
+func chanSize(n int) int {
+	if n > int(maxChanSize) {
+		return int(maxChanSize)
+	}
+
+	return n
 }
 
 
-func (pwp *WorkerPool) exec(job Job, wid, wcnt, avlwcnt int32) {
+/* *****************************************************************************
+Description : Executes a job on the worker wid and delivers its execution status.
+
+Receiver    : *WorkerPool
+
+Implements  : NA
+
+Arguments   :
+1> ctx context.Context: Context passed to Start(). Once it's done, a keyed job isn't followed by
+the jobs parked behind it.
+2> quit <-chan struct{}: Closed when the worker-pool is stopped, same as for ctx.
+3> job Job: Job to be executed.
+4> wid int32: ID of the worker the job is assigned to.
+5> wcnt int32: No. of workers in action when the job was assigned.
+6> avlwcnt int32: No. of available workers when the job was assigned.
+
+Return value: NA
+
+Additional note:
+Execution status is delivered to the job handle and, if the worker-pool was created with
+WorkerPoolOptions.IsResponse set, to the results channel. If the result implements
+JobResultProcessor, it's delivered by the result processing stage instead.
+A keyed job is followed by the jobs parked behind it in its key's mailbox, on the same worker.
+Once the worker-pool is stopped, the parked jobs are left in the mailbox. They're run once it's
+started again, unless Shutdown() returns them.
+A job cancelled, through Cancel() or its own context, see WithContext(), by the time it's
+dispatched isn't run.
+***************************************************************************** */
+func (pwp *WorkerPool) exec(ctx context.Context, quit <-chan struct{}, job Job, wid, wcnt, avlwcnt int32) {
+	atomic.AddInt32(&pwp.busycnt, 1)
 	defer func() {
-		pwp.workers <- wid  // one more worker is made available.
-		atomic.AddInt32(&pwp.wcnt, -1)
-		atomic.AddInt32(&pwp.avlwcnt, 1)
+		atomic.AddInt32(&pwp.busycnt, -1)
+		pwp.releaseWorker(wid)  // one more worker is made available.
 		pwp.wg.Done()
 	}()
 
-	c := make(chan JobStatus, 1)
-	wg := sync.WaitGroup{}
-	wg.Add(1)  // one go-routine for the job process method.
-	go func(pwg *sync.WaitGroup) {
-		defer pwg.Done()
+	for {
+		jctx, err := pwp.beginRun(job, wid)
+		switch {
+			case err == ErrJobCancelled:
+				// the job has been cancelled through Cancel() before it's run, its handle is resolved already.
+
+			case err != nil:
+				// the job has been cancelled through its own context before it's run, it's not run at all.
+				pwp.deliver(job, JobStatus{id: job.id, name: job.name, err: err})
+
+			default:
+				started := time.Now()
+				js := pwp.runWithRetry(jctx, job)
+				pwp.endRun(job, &js)
+				pwp.stats.observeRunTime(time.Since(started))
+				pwp.deadLetter(job, js)
+
+				// result processing stage delivers the execution status, if there's one.
+				if !pwp.handoffResult(job, js) {
+					pwp.deliver(job, js)
+				}
+		}
 
-		js := JobStatus{}
-		js.data, js.err = job.data.Process(pwp.GetContext(), pwp.cancelFunc, pwp.maxJobCnt, pwp.shouldTerminate)
-		c <- js
-	}(&wg)
+		if job.key == "" {
+			return
+		}
 
-	select {
-		case <-pwp.GetContext().Done():
-			wg.Wait()
+		// runs the next parked job of the key, if any, on the same worker.
+		if err := pwp.GetContext().Err(); err != nil {
+			pwp.dropKeyed(job.key, err)
+			return
+		}
+
+		if isDone(ctx, quit) {
+			pwp.leaveKeyed(job.key)
 			return
+		}
 
-		// TODO: result processing.
-		case <-c:
-			wg.Wait()
+		next, ok := pwp.nextKeyed(job.key)
+		if !ok {
+			return
+		}
+		job = next
 	}
+}
 
-	return
+
+/* *****************************************************************************
+Description : Runs Process() method of the job, once.
+
+Receiver    : *WorkerPool
+
+Implements  : NA
+
+Arguments   :
+1> ctx context.Context: Context the job is run with, see beginRun().
+2> job Job: Job to be run.
+3> attempt int: Attempt no., made available to the job through AttemptFromContext().
+
+Return value:
+1> JobStatus: Job execution status.
+
+Additional note:
+Process() receives a context derived from ctx. If the job has a timeout - its
+own through Timeouter or WorkerPoolOptions.JobTimeout - the context expires after the timeout.
+If the job is still running by then, its error is context.DeadlineExceeded and it's counted as
+timed out.
+***************************************************************************** */
+func (pwp *WorkerPool) run(ctx context.Context, job Job, attempt int) JobStatus {
+	js := JobStatus {
+		id: job.id,
+		name: job.name,
+		attempts: attempt,
+	}
+
+	job.rec.mu.Lock()
+	job.rec.attempts = attempt
+	job.rec.mu.Unlock()
+
+	ctx = context.WithValue(ctx, attemptKey, attempt)
+	if d := pwp.timeoutOf(job); d > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d)
+		defer cancel()
+	}
+
+	ctl := &jobControl{pwp: pwp, job: job, attempt: attempt}
+	js.data, js.err = pwp.invoke(job, func() (interface{}, error) {
+		return pwp.processorOf(job).Process(ctx, ctl)
+	})
+
+	// the job context expired, and not because of the worker-pool context.
+	if (ctx.Err() == context.DeadlineExceeded) && (pwp.GetContext().Err() == nil) {
+		if !errors.Is(js.err, context.DeadlineExceeded) {
+			js.data, js.err = nil, context.DeadlineExceeded
+		}
+	}
+
+	return js
+}
+
+
+// timeout of the job. job's own timeout, through Timeouter, precedes the worker-pool default.
+func (pwp *WorkerPool) timeoutOf(job Job) time.Duration {
+	if t, ok := unwrapJob(job.data).(Timeouter); ok {
+		if d := t.Timeout(); d > 0 {
+			return d
+		}
+	}
+
+	return pwp.jobTimeout
+}
+
+
+/* *****************************************************************************
+Description : Returns the no. of jobs that have timed out.
+
+Receiver    : *WorkerPool
+
+Implements  : NA
+
+Arguments   : NA
+
+Return value:
+1> uint64: No. of jobs whose context expired, as per their timeout, before they finished.
+
+Additional note:
+Same as Stats().TimedOut.
+***************************************************************************** */
+func (pwp *WorkerPool) GetTimeoutCnt() uint64 {
+	return pwp.stats.jobCounts().TimedOut
 }
 
 
 /* *****************************************************************************
-Description : Starts the worker-pool.
+Description : Invokes a method of the job, recovers if it panics.
+
+Receiver    : *WorkerPool
+
+Implements  : NA
+
+Arguments   :
+1> job Job: Job whose method is invoked.
+2> f func() (interface{}, error): Invokes the method.
+
+Return value:
+1> interface{}: Value returned by f.
+2> error: Error returned by f, or *PanicError if f panics.
+
+Additional note:
+A panic is reported to WorkerPoolOptions.OnPanic, if set, and is then the job error. Thus, a
+panicking job doesn't bring down the process.
+***************************************************************************** */
+func (pwp *WorkerPool) invoke(job Job, f func() (interface{}, error)) (data interface{}, err error) {
+	defer func() {
+		if panicState := recover(); panicState != nil {
+			pe := &PanicError {
+				Value: panicState,
+				Stack: debug.Stack(),
+				JobID: job.id,
+				JobName: job.name,
+			}
+			data = nil
+			err = pe
+
+			if pwp.onPanic != nil {
+				pwp.onPanic(pe)
+			}
+		}
+	}()
+
+	return f()
+}
+
+
+// deliver resolves the job handle and publishes the job execution status on the results
+// channel. Publishing waits for a reader unless the worker-pool context is cancelled.
+func (pwp *WorkerPool) deliver(job Job, js JobStatus) {
+	pwp.resolve(job, js)
+
+	if !pwp.isResponse {
+		return
+	}
+
+	select {
+		case pwp.resultq <- js:
+		case <-pwp.GetContext().Done():
+	}
+}
+
+
+/* *****************************************************************************
+Description : Returns the channel on which execution status of each job is published.
+
+Receiver    : *WorkerPool
+
+Implements  : NA
+
+Arguments   : NA
+
+Return value:
+1> <-chan JobStatus: Results channel, nil unless the worker-pool was created with
+WorkerPoolOptions.IsResponse set.
+
+Additional note:
+A worker waits for the status to be read before it's made available again. Therefore, the
+upstream should keep reading this channel for as long as the worker-pool is in action.
+***************************************************************************** */
+func (pwp *WorkerPool) Results() <-chan JobStatus {
+	return pwp.resultq
+}
+
+
+/* *****************************************************************************
+Description : Starts the worker-pool. Returns once the worker-pool is stopped.
 
 Receiver    : *WorkerPool 
 
@@ -230,57 +567,159 @@ Implements  : NA
 
 Arguments   :
 1> ctx context.Context: Context passed from the upstream.
-2> pwg *sync.WaitGroup: Passed 
+2> pwg *sync.WaitGroup: Passed from the upstream, marked done once Start() returns. May be nil.
 
 Return value: NA
 
 Additional note:
-ctx and pwg combiningly used for concurrency control.
+- ctx and pwg combiningly used for concurrency control.
+- Start() returns right away if the worker-pool is already running, paused, or draining. Thus, a
+worker-pool is started only once while it's in action.
+- A stopped worker-pool may be started again. Jobs that were still queued when it was stopped
+are then executed. If the previous run is still waiting for its running jobs, Start() first waits
+for them to finish.
+- On cancellation of ctx or the worker-pool context, Start() stops dispatching jobs, even while
+the worker-pool is paused or waiting for a job, waits for the running jobs to finish and the
+worker-pool is stopped.
 ***************************************************************************** */
 func (pwp *WorkerPool) Start(ctx context.Context, pwg *sync.WaitGroup) {
 	defer func() {
 		if panicState := recover(); panicState != nil {
-			fmt.Printf("ERROR: Recovered from panic: state: %#v", panicState)
+			fmt.Printf("ERROR: Recovered from panic: state: %#v\n", panicState)
 		}
 
-		pwg.Done()
+		if pwg != nil {
+			pwg.Done()
+		}
 	}()
 
 	pwp.singletonCtrl.Lock()
-	if pwp.startFlag {
-		pwp.singletonCtrl.Unlock()
-		return
+	for {
+		if (pwp.state == StateRunning) || (pwp.state == StatePaused) || (pwp.state == StateDraining) {
+			pwp.singletonCtrl.Unlock()
+			return
+		}
+
+		// the previous run may still be waiting for its running jobs.
+		if !pwp.isRunOver() {
+			prev := pwp.stopped
+			pwp.singletonCtrl.Unlock()
+			<-prev
+			pwp.singletonCtrl.Lock()
+			continue  // state may have been changed meanwhile.
+		}
+		break
 	}
-	pwp.startFlag = true
-	pwp.stopFlag = false
+	from := pwp.state
+	pwp.state = StateRunning
+	quit := make(chan struct{})
+	pwp.quit = quit
+	stopped := make(chan struct{})
+	pwp.stopped = stopped
+	pwp.resumed = make(chan struct{})
+	close(pwp.resumed)
+	pwp.rearmHalt()
+	pwp.openQueue()
 	pwp.singletonCtrl.Unlock()
+	pwp.notifyState(from, StateRunning)
+
+	defer func() {
+		pwp.singletonCtrl.Lock()
+		from := pwp.state
+		pwp.state = StateStopped
+		pwp.closeHalt()
+		pwp.singletonCtrl.Unlock()
+		pwp.closeQueue()
+		pwp.notifyState(from, StateStopped)
+		close(stopped)
+	}()
+
+	rstop := make(chan struct{})
+	rdone := make(chan struct{})
+	go pwp.runResultStage(rstop, rdone)
+
+	if pwp.autoscale != nil {
+		go pwp.runAutoscaler(ctx, stopped)
+	}
+
+	if len(pwp.terminate) > 0 {
+		// counts as of now, before any job of this run is dispatched.
+		go pwp.runTerminator(ctx, stopped, time.Now(), pwp.stats.jobCounts())
+	}
+
+	// waits for each exec() method finish its respective job, and then for the result
+	// processing stage.
+	finish := func() {
+		pwp.wg.Wait()
+		close(rstop)  // no more results are handed off to the result processing stage.
+		<-rdone
+	}
+
+	// keyed jobs parked by the previous run are dispatched first, their keys are claimed already.
+	resume := pwp.resumeKeyed()
 
 	for {
-		select {
-			//case <-pwp.GetContext().Done():
-			case <-ctx.Done():
-				pwp.wg.Wait()  // waits for each exec() method finish its respective job.
+		if !pwp.awaitResume(ctx, quit) {
+			finish()
+			return
+		}
+
+		wid, ok := pwp.acquireWorker(ctx, quit)  // waits for a worker available for the next job.
+		if !ok {
+			finish()
+			return
+		}
+
+		if len(resume) > 0 {
+			job, ok := pwp.nextKeyed(resume[0])
+			resume = resume[1:]
+			if !ok {
+				pwp.releaseWorker(wid)  // parked jobs have been taken meanwhile, the key is released.
+				continue
+			}
+			wcnt := atomic.LoadInt32(&pwp.wcnt)
+			avlwcnt := atomic.LoadInt32(&pwp.avlwcnt)
+			pwp.wg.Add(1)
+			go pwp.exec(ctx, quit, job, wid, wcnt, avlwcnt)
+			continue
+		}
+
+		job, ok := pwp.dequeue(ctx, quit)
+		if !ok {
+			pwp.releaseWorker(wid)
+			if pwp.drained() {  // Shutdown() is in progress and there are no more jobs.
+				finish()
 				return
+			}
+			continue
+		}
 
-			case job := <-pwp.jobq:  // there's a job for one of the workers.
-				select {
-					case wid := <-pwp.workers:
-						if wid > 0 {
-							wcnt := atomic.AddInt32(&pwp.wcnt, 1)
-							avlwcnt := atomic.AddInt32(&pwp.avlwcnt, -1)
-							//jobcnt := atomic.AddUint64(&pwp.jobcnt, 1)
-							atomic.AddUint64(&pwp.jobcnt, 1)
-							pwp.wg.Add(1)
-							//time.Sleep(time.Duration(helper.RandomInt(1000, 2000)) * time.Millisecond)
-							go pwp.exec(job, wid, wcnt, avlwcnt)
-						}
-						break
-				}
-				break
+		if !pwp.claimKey(job) {
+			pwp.releaseWorker(wid)  // job is parked behind the running job of its key.
+			continue
 		}
+
+		wcnt := atomic.LoadInt32(&pwp.wcnt)
+		avlwcnt := atomic.LoadInt32(&pwp.avlwcnt)
+		pwp.wg.Add(1)
+		//time.Sleep(time.Duration(helper.RandomInt(1000, 2000)) * time.Millisecond)
+		go pwp.exec(ctx, quit, job, wid, wcnt, avlwcnt)
 	}
+}
 
-	return
+
+// true if there's no run of Start() in progress. singletonCtrl must be held.
+func (pwp *WorkerPool) isRunOver() bool {
+	if pwp.stopped == nil {
+		return true
+	}
+
+	select {
+		case <-pwp.stopped:
+			return true
+		default:
+			return false
+	}
 }
 
 
@@ -295,21 +734,96 @@ Arguments   : NA
 
 Return value: NA
 
-Additional note: NA
+Additional note:
+- No more jobs are accepted once the worker-pool is stopped. Submit...() methods return
+ErrPoolStopped. Start() returns once the running jobs finish.
+- Queued jobs are retained, they're executed if the worker-pool is started again. Use
+Shutdown() to rather have them executed, or returned, right away.
+- Stop() may be invoked concurrently and more than once, only the first invocation has any effect.
 ***************************************************************************** */
 func (pwp *WorkerPool) Stop() {
 	pwp.singletonCtrl.Lock()
-	defer pwp.singletonCtrl.Unlock()
-
-	if pwp.stopFlag {
+	from := pwp.state
+	if from == StateStopped {
+		pwp.singletonCtrl.Unlock()
 		return
 	}
 
-	pwp.stopFlag = true
-	pwp.startFlag = false
-
-	close(pwp.jobq)
-	close(pwp.workers)
+	pwp.state = StateStopped
+	pwp.closeHalt()
+	pwp.closeQueue()
+	if from != StateCreated {
+		close(pwp.quit)
+	}
+	pwp.singletonCtrl.Unlock()
+	pwp.notifyState(from, StateStopped)
 
 	return
 }
+
+
+/* *****************************************************************************
+Description : Shuts down a worker-pool gracefully.
+
+Receiver    : *WorkerPool
+
+Implements  : NA
+
+Arguments   :
+1> ctx context.Context: Bounds the wait for the queued and the running jobs to finish.
+
+Return value:
+1> []Job: Jobs that couldn't be run before ctx is done. Their handles are resolved with
+ErrPoolStopped.
+2> error: ctx.Err() if ctx is done before the queued jobs have been run.
+
+Additional note:
+- No more jobs are accepted once Shutdown() is invoked. The worker-pool is draining, workers go
+on executing all the queued jobs, even if it was paused. Shutdown() returns once they all finish.
+- If ctx is done before that, the worker-pool is stopped and the jobs still in the queue are
+returned. Jobs already running aren't affected, Start() returns once they finish.
+- If the worker-pool isn't running, all the queued jobs are returned right away.
+- Scheduled jobs that aren't due yet aren't waited for, they're returned as well. So are the
+keyed jobs still parked behind the running job of their key.
+***************************************************************************** */
+func (pwp *WorkerPool) Shutdown(ctx context.Context) ([]Job, error) {
+	pwp.singletonCtrl.Lock()
+	from := pwp.state
+	stopped := pwp.stopped
+	switch from {
+		case StateCreated, StateStopped:
+			pwp.state = StateStopped
+			pwp.closeHalt()
+			pwp.closeQueue()
+			stopped = nil
+
+		case StateRunning, StatePaused:
+			pwp.state = StateDraining
+			pwp.closeQueue()
+			if from == StatePaused {
+				close(pwp.resumed)
+			}
+	}
+	to := pwp.state
+	pwp.singletonCtrl.Unlock()
+	pwp.notifyState(from, to)
+	sjobs := pwp.takeScheduled()
+
+	var err error
+	if stopped != nil {
+		select {
+			case <-stopped:
+			case <-ctx.Done():
+				err = ctx.Err()
+				pwp.Stop()
+		}
+	}
+
+	jobs := append(pwp.takeQueued(), pwp.takeKeyed()...)
+	jobs = append(jobs, sjobs...)
+	for _, j := range jobs {
+		pwp.resolve(j, JobStatus{id: j.id, name: j.name, err: ErrPoolStopped})
+	}
+
+	return jobs, err
+}
@@ -0,0 +1,181 @@
+/* *****************************************************************************
+Copyright (c) 2023, sameeroak1110 (sameeroak1110@gmail.com)
+BSD 3-Clause License.
+
+Package     : github.com/sameeroak1110/gowp
+Filename    : github.com/sameeroak1110/gowp/gowp_test.go
+File-type   : GoLang test file
+
+Compiler/Runtime: go version go1.20.5 linux/amd64
+
+Version History
+Version     : 1.0
+Author      : Sameer Oak (sameeroak1110@gmail.com)
+
+Description :
+- Helpers shared by the tests of the package.
+***************************************************************************** */
+package gowp
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// upper bound of any wait in the tests.
+const testWait time.Duration = 5 * time.Second
+
+var errTest = errors.New("test error")
+
+// running worker-pool of a test.
+type testPool struct {
+	*WorkerPool
+	cancel context.CancelFunc
+	wg sync.WaitGroup
+}
+
+
+// creates a worker-pool and starts it. it's stopped once the test ends.
+func startPool(t *testing.T, opts ...Option) *testPool {
+	t.Helper()
+
+	tp := newPool(t, opts...)
+	tp.start(t)
+
+	return tp
+}
+
+
+// creates a worker-pool without starting it. its context is cancelled once the test ends.
+func newPool(t *testing.T, opts ...Option) *testPool {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	pwp, err := New(ctx, opts...)
+	if err != nil {
+		cancel()
+		t.Fatalf("New(): %v", err)
+	}
+
+	tp := &testPool{WorkerPool: pwp, cancel: cancel}
+	t.Cleanup(func() {
+		tp.Stop()
+		tp.cancel()
+		tp.wg.Wait()
+	})
+
+	return tp
+}
+
+
+// starts the worker-pool and waits for it to be running.
+func (tp *testPool) start(t *testing.T) {
+	t.Helper()
+
+	tp.wg.Add(1)
+	go tp.Start(context.Background(), &tp.wg)
+	waitFor(t, "worker-pool running", func() bool {
+		return tp.State() == StateRunning
+	})
+}
+
+
+// waits for all the runs of Start() to return.
+func (tp *testPool) waitStart(t *testing.T) {
+	t.Helper()
+
+	done := make(chan struct{})
+	go func() {
+		tp.wg.Wait()
+		close(done)
+	}()
+
+	select {
+		case <-done:
+		case <-time.After(testWait):
+			t.Fatal("Start() didn't return")
+	}
+}
+
+
+// polls cond until it's true, fails the test after testWait.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(testWait)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+
+// waits for the job handle, fails the test after testWait.
+func wait(t *testing.T, h *JobHandle) (interface{}, error) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), testWait)
+	defer cancel()
+
+	select {
+		case <-h.Done():
+		case <-ctx.Done():
+			t.Fatalf("job %d didn't finish", h.GetID())
+	}
+
+	return h.Wait(ctx)
+}
+
+
+// submits the job, fails the test if it can't be.
+func mustSubmit(t *testing.T, tp *testPool, job JobProcessor, opts ...SubmitOption) *JobHandle {
+	t.Helper()
+
+	h, err := tp.SubmitWithHandle(context.Background(), job, opts...)
+	if err != nil {
+		t.Fatalf("SubmitWithHandle(): %v", err)
+	}
+
+	return h
+}
+
+
+// job that returns v.
+func valueJob(v interface{}) JobProcessor {
+	return Func("value", func(context.Context) (interface{}, error) {
+		return v, nil
+	})
+}
+
+
+// job that returns err.
+func errJob(err error) JobProcessor {
+	return Func("error", func(context.Context) (interface{}, error) {
+		return nil, err
+	})
+}
+
+
+// job that blocks until release is closed or its context is done. started is closed once it runs.
+func blockJob(started chan<- struct{}, release <-chan struct{}) JobProcessor {
+	var once sync.Once
+	return Func("block", func(ctx context.Context) (interface{}, error) {
+		if started != nil {
+			once.Do(func() {
+				close(started)
+			})
+		}
+
+		select {
+			case <-release:
+				return nil, nil
+			case <-ctx.Done():
+				return nil, ctx.Err()
+		}
+	})
+}
@@ -0,0 +1,285 @@
+/* *****************************************************************************
+Copyright (c) 2023, sameeroak1110 (sameeroak1110@gmail.com)
+BSD 3-Clause License.
+
+Package     : github.com/sameeroak1110/gowp
+Filename    : github.com/sameeroak1110/gowp/group.go
+File-type   : GoLang source code file
+
+Compiler/Runtime: go version go1.20.5 linux/amd64
+
+Version History
+Version     : 1.0
+Author      : Sameer Oak (sameeroak1110@gmail.com)
+
+Description :
+- Job contexts. A job submitted with WithContext() is cancelled along with its own context as
+well as the worker-pool context. A job whose context is done before it's dispatched isn't run.
+- Job groups. A Group is a batch of related jobs submitted to a worker-pool, awaited together
+through Wait(). Its jobs run with the group context, thus cancelling the group cancels only its
+own jobs, and never the worker-pool.
+- In GroupFailFast mode, the first failure cancels the rest of the group and is what Wait()
+returns. In GroupCollectAll mode, all the jobs run and Wait() returns all their errors joined.
+***************************************************************************** */
+package gowp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// GroupMode decides how a Group reacts to a failed job.
+type GroupMode int
+
+const (
+	GroupFailFast GroupMode = iota  // the first failure cancels the group. default.
+	GroupCollectAll                 // failures don't cancel the group, all of them are reported.
+)
+
+// configures a Group.
+type GroupOption func(*Group)
+
+// Group is a batch of jobs submitted to a worker-pool and awaited together.
+type Group struct {
+	pwp *WorkerPool
+	ctx context.Context
+	cancel context.CancelFunc
+	mode GroupMode
+	sem chan struct{}  // holds a token for each unfinished job, nil means no limit.
+	wg sync.WaitGroup  // unfinished jobs.
+	emu sync.Mutex     // guards errs.
+	errs []error       // in the order they occurred.
+}
+
+
+// WithContext sets the job's own context. The job is cancelled once ctx is done, and isn't run at
+// all if ctx is done before it's dispatched. Only the job is affected, not the worker-pool.
+func WithContext(ctx context.Context) SubmitOption {
+	return func(sc *submitConfig) {
+		sc.ctx = ctx
+	}
+}
+
+
+// error of the job's own context, nil if it's not done or there isn't one.
+func (j Job) ctxErr() error {
+	if j.ctx == nil {
+		return nil
+	}
+
+	return j.ctx.Err()
+}
+
+
+// returns a context derived from ctx that's also cancelled once other is done.
+func mergeCancel(ctx, other context.Context) (context.Context, context.CancelFunc) {
+	mctx, cancel := context.WithCancel(ctx)
+	go func() {
+		select {
+			case <-other.Done():
+				cancel()
+			case <-mctx.Done():
+		}
+	}()
+
+	return mctx, cancel
+}
+
+
+// WithGroupMode sets how the group reacts to a failed job. Default is GroupFailFast.
+func WithGroupMode(mode GroupMode) GroupOption {
+	return func(g *Group) {
+		g.mode = mode
+	}
+}
+
+
+// WithGroupLimit limits the no. of unfinished, i.e., queued or running, jobs of the group to n.
+// Go() then waits for a job to finish. n less than 1 means no limit, which is the default.
+func WithGroupLimit(n int) GroupOption {
+	return func(g *Group) {
+		if n > 0 {
+			g.sem = make(chan struct{}, n)
+		} else {
+			g.sem = nil
+		}
+	}
+}
+
+
+/* *****************************************************************************
+Description : Creates a group of jobs on the worker-pool.
+
+Receiver    : *WorkerPool
+
+Implements  : NA
+
+Arguments   :
+1> ctx context.Context: Parent of the group context. The group is cancelled along with it.
+2> opts ...GroupOption: Group options, WithGroupMode(), WithGroupLimit().
+
+Return value:
+1> *Group: Newly created group.
+
+Additional note:
+A group is used once, i.e., jobs aren't added to it once Wait() returns.
+***************************************************************************** */
+func (pwp *WorkerPool) NewGroup(ctx context.Context, opts ...GroupOption) *Group {
+	g := &Group{pwp: pwp}
+	g.ctx, g.cancel = context.WithCancel(ctx)
+	for _, opt := range opts {
+		opt(g)
+	}
+
+	return g
+}
+
+
+// GetContext returns the group context. It's done once the group is cancelled, a job fails in
+// GroupFailFast mode, or Wait() returns.
+func (g *Group) GetContext() context.Context {
+	return g.ctx
+}
+
+
+// Cancel cancels the group. Its queued jobs aren't run, its running jobs have their context
+// cancelled. Other jobs of the worker-pool aren't affected.
+func (g *Group) Cancel() {
+	g.cancel()
+}
+
+
+// records an error of the group. the first one cancels the group in GroupFailFast mode.
+func (g *Group) record(err error) {
+	g.emu.Lock()
+	defer g.emu.Unlock()
+
+	g.errs = append(g.errs, err)
+	if g.mode == GroupFailFast {
+		g.cancel()
+	}
+
+	return
+}
+
+
+/* *****************************************************************************
+Description : Submits a job as a part of the group.
+
+Receiver    : *Group
+
+Implements  : NA
+
+Arguments   :
+1> job JobProcessor: Job to be executed.
+2> opts ...SubmitOption: Job options, same as WorkerPool.Submit(). The job context is always the
+group context.
+
+Return value:
+1> error: nil once the job has been submitted. The group context's error if the group is done
+before there's room in the group or in the job queue, or same as WorkerPool.Submit() otherwise.
+The error is also reported by Wait().
+
+Additional note:
+- Waits for room in the group if it has a limit, see WithGroupLimit(), and then for room in the
+job queue.
+- Unlike other jobs, a job of the group isn't retained once the worker-pool is stopped before it
+runs. It's cancelled, and reported, with ErrPoolStopped.
+***************************************************************************** */
+func (g *Group) Go(job JobProcessor, opts ...SubmitOption) error {
+	g.wg.Add(1)
+
+	if g.sem != nil {
+		select {
+			case g.sem <- struct{}{}:
+			case <-g.ctx.Done():
+				err := g.ctx.Err()
+				g.record(err)
+				g.wg.Done()
+				return err
+		}
+	}
+
+	release := func() {
+		if g.sem != nil {
+			<-g.sem
+		}
+		g.wg.Done()
+	}
+
+	opts = append(opts[:len(opts):len(opts)], WithContext(g.ctx))
+	h, err := g.pwp.SubmitWithHandle(g.ctx, job, opts...)
+	if err != nil {
+		g.record(err)
+		release()
+		return err
+	}
+
+	halted := g.pwp.haltSignal()
+	go func() {
+		defer release()
+
+		select {
+			case <-h.Done():
+
+			case <-g.pwp.GetContext().Done():  // job isn't going to be run.
+				g.record(g.pwp.GetContext().Err())
+				return
+
+			case <-g.ctx.Done():
+				// a waiting job isn't going to be run, it's resolved right away rather than once it's
+				// dispatched. a running job is waited for.
+				g.pwp.cancelWaiting(h.GetID(), fmt.Errorf("%w: %w", ErrJobCancelled, g.ctx.Err()))
+				<-h.Done()
+
+			case <-halted:
+				// a waiting job would be run only if the worker-pool is started again, it's cancelled
+				// rather than having Wait() hang. a running job is waited for.
+				g.pwp.cancelWaiting(h.GetID(), ErrPoolStopped)
+				<-h.Done()
+		}
+
+		if _, err := h.Wait(context.Background()); err != nil {
+			g.record(err)
+		}
+	}()
+
+	return nil
+}
+
+
+/* *****************************************************************************
+Description : Waits for all the jobs of the group to finish.
+
+Receiver    : *Group
+
+Implements  : NA
+
+Arguments   : NA
+
+Return value:
+1> error: nil if all the jobs succeeded. In GroupFailFast mode, the first error. In
+GroupCollectAll mode, all the errors joined through errors.Join().
+
+Additional note:
+The group context is cancelled once all the jobs have finished.
+***************************************************************************** */
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	g.cancel()
+
+	g.emu.Lock()
+	defer g.emu.Unlock()
+
+	if len(g.errs) == 0 {
+		return nil
+	}
+
+	if g.mode == GroupFailFast {
+		return g.errs[0]
+	}
+
+	return errors.Join(g.errs...)
+}
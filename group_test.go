@@ -0,0 +1,169 @@
+package gowp
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGroupModes(t *testing.T) {
+	errOther := errors.New("other error")
+
+	tests := []struct {
+		name string
+		mode GroupMode
+		errs []error  // error of each job, nil for a job that blocks until cancelled.
+		want []error  // errors Wait() is expected to wrap.
+		failed uint64
+		cancelled uint64
+	}{
+		{"fail fast, all succeed", GroupFailFast, []error{errNone, errNone, errNone}, nil, 0, 0},
+		{"fail fast cancels the rest", GroupFailFast, []error{nil, nil, errTest}, []error{errTest}, 1, 2},
+		{"collect all, all succeed", GroupCollectAll, []error{errNone, errNone}, nil, 0, 0},
+		{"collect all joins errors", GroupCollectAll, []error{errTest, errNone, errOther}, []error{errTest, errOther}, 2, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sink := NewMemoryDeadLetterSink()
+			tp := startPool(t, WithWorkers(int32(len(tt.errs))), WithDeadLetterSink(sink, nil))
+
+			var running int32
+			g := tp.NewGroup(context.Background(), WithGroupMode(tt.mode))
+			for _, err := range tt.errs {
+				err := err
+				job := Func("member", func(ctx context.Context) (interface{}, error) {
+					if err == nil {
+						atomic.AddInt32(&running, 1)
+						<-ctx.Done()
+						return nil, ctx.Err()
+					}
+					if err != errNone {
+						// fails once the blocking members run, so that they're cancelled while running.
+						for atomic.LoadInt32(&running) < int32(tt.cancelled) {
+							time.Sleep(time.Millisecond)
+						}
+						return nil, err
+					}
+					return nil, nil
+				})
+				if err := g.Go(job); err != nil {
+					t.Fatalf("Go(): %v", err)
+				}
+			}
+
+			err := g.Wait()
+			if (len(tt.want) == 0) != (err == nil) {
+				t.Fatalf("Wait(): %v, want %v", err, tt.want)
+			}
+			for _, want := range tt.want {
+				if !errors.Is(err, want) {
+					t.Fatalf("Wait(): %v, want %v", err, want)
+				}
+			}
+
+			waitFor(t, "jobs completed", func() bool {
+				return tp.Stats().Completed == uint64(len(tt.errs))
+			})
+			st := tp.Stats()
+			if (st.Failed != tt.failed) || (st.Cancelled != tt.cancelled) {
+				t.Fatalf("failed %d, cancelled %d, want %d, %d", st.Failed, st.Cancelled, tt.failed, tt.cancelled)
+			}
+			if dls, _ := sink.List(); uint64(len(dls)) != tt.failed {
+				t.Fatalf("%d dead letters, want %d", len(dls), tt.failed)
+			}
+		})
+	}
+}
+
+// marks a group member that succeeds, see TestGroupModes.
+var errNone = errors.New("no error")
+
+
+func TestGroupCancelledBeforeRun(t *testing.T) {
+	tp := startPool(t, WithWorkers(1))
+	started, release := make(chan struct{}), make(chan struct{})
+	defer close(release)
+	if err := tp.Submit(context.Background(), blockJob(started, release)); err != nil {
+		t.Fatalf("Submit(): %v", err)
+	}
+	<-started
+
+	g := tp.NewGroup(context.Background())
+	if err := g.Go(valueJob(1)); err != nil {
+		t.Fatalf("Go(): %v", err)
+	}
+	g.Cancel()
+
+	if err := g.Wait(); !errors.Is(err, ErrJobCancelled) || !errors.Is(err, context.Canceled) {
+		t.Fatalf("Wait(): %v, want ErrJobCancelled wrapping context.Canceled", err)
+	}
+}
+
+
+func TestGroupPoolStopped(t *testing.T) {
+	tp := startPool(t, WithWorkers(1))
+	started, release := make(chan struct{}), make(chan struct{})
+
+	g := tp.NewGroup(context.Background(), WithGroupMode(GroupCollectAll))
+	if err := g.Go(blockJob(started, release)); err != nil {
+		t.Fatalf("Go(): %v", err)
+	}
+	<-started
+	for i := 0; i < 3; i++ {
+		if err := g.Go(valueJob(i)); err != nil {
+			t.Fatalf("Go(): %v", err)
+		}
+	}
+
+	tp.Stop()
+	close(release)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- g.Wait()
+	}()
+
+	select {
+		case err := <-done:
+			if !errors.Is(err, ErrPoolStopped) {
+				t.Fatalf("Wait(): %v, want ErrPoolStopped", err)
+			}
+		case <-time.After(testWait):
+			t.Fatal("Wait() hangs once the worker-pool is stopped")
+	}
+}
+
+
+func TestGroupLimit(t *testing.T) {
+	tp := startPool(t, WithWorkers(4))
+
+	var cur, peak int32
+	g := tp.NewGroup(context.Background(), WithGroupLimit(2))
+	for i := 0; i < 8; i++ {
+		job := Func("limited", func(context.Context) (interface{}, error) {
+			n := atomic.AddInt32(&cur, 1)
+			for {
+				p := atomic.LoadInt32(&peak)
+				if (n <= p) || atomic.CompareAndSwapInt32(&peak, p, n) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			atomic.AddInt32(&cur, -1)
+			return nil, nil
+		})
+		if err := g.Go(job); err != nil {
+			t.Fatalf("Go(): %v", err)
+		}
+	}
+
+	if err := g.Wait(); err != nil {
+		t.Fatalf("Wait(): %v", err)
+	}
+	if p := atomic.LoadInt32(&peak); p > 2 {
+		t.Fatalf("%d jobs ran at once, limit is 2", p)
+	}
+}
@@ -19,6 +19,7 @@ package gowp
 
 import (
 	"context"
+	"time"
 )
 
 // - A type that implements JobProcessor is assumed to have all the necessary data.
@@ -32,7 +33,53 @@ import (
 // - However, there may be a circumstance where the business logic need is to terminate the running
 // job instantly when it receives the context cancellation.
 // - Therefore, it's upto the implementation how to handle the upstream context.
+// - Process() also receives the worker-pool wide cancel function, WorkerPoolOptions.MaxJobCnt, and
+// WorkerPoolOptions.ShouldTerminate. New jobs should rather implement JobProcessorV2.
 type JobProcessor interface {
 	GetName() string
 	Process(context.Context, context.CancelFunc, int, bool) (interface{}, error)
 }
+
+// - JobProcessorV2 is the preferred interface of a job. Rather than the worker-pool wide cancel
+// function and termination parameters, Process() receives JobControl scoped to the job.
+// - A JobProcessorV2 is submitted through V2() adapter, e.g., pwp.Submit(ctx, gowp.V2(job)).
+// - Optional interfaces like Timeouter and Retryer are looked up on the JobProcessorV2 itself.
+type JobProcessorV2 interface {
+	GetName() string
+	Process(context.Context, JobControl) (interface{}, error)
+}
+
+// JobControl lets a running job know about, and act on, itself. Valid only while Process() runs.
+type JobControl interface {
+	JobID() uint64
+	Attempt() int                                // attempt no., starts at 1.
+	RequestStop()                                // asks the worker-pool to shut down gracefully, returns right away.
+	ReportProgress(percent float64, msg string)  // see JobHandle.Progress() and WorkerPoolOptions.OnProgress.
+	Metadata(key string) (string, bool)          // see WithMetadata().
+}
+
+// - JobResultProcessor is optional. If the value returned by Process() method of a JobProcessor
+// implements it, the worker-pool invokes ProcessResult() on the result processing stage once
+// Process() returns without an error.
+// - The result processing stage has its own set of workers, sized independently of the workers
+// that execute Process(). This keeps slow result handling, for instance writing the result to a
+// db, off the workers that execute jobs.
+// - Error returned by ProcessResult() is part of the job execution status and is also passed on to
+// WorkerPoolOptions.OnResultError, if set.
+type JobResultProcessor interface {
+	ProcessResult(context.Context) (interface{}, error)
+}
+
+// - Timeouter is optional. A JobProcessor implements it to have its own timeout, which precedes
+// WorkerPoolOptions.JobTimeout.
+// - Context passed to Process() expires after the timeout. A non-positive timeout means the
+// worker-pool default is used.
+type Timeouter interface {
+	Timeout() time.Duration
+}
+
+// - Retryer is optional. A JobProcessor implements it to have its own retry policy, which precedes
+// WorkerPoolOptions.RetryPolicy. A nil policy means the worker-pool default is used.
+type Retryer interface {
+	RetryPolicy() *RetryPolicy
+}
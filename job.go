@@ -1,23 +1,224 @@
 package gowp
 
 import (
-	"fmt"
+	"context"
+	"errors"
 	"sync/atomic"
+	"time"
 )
 
 
-func (pwp *WorkerPool) AddJob(job JobProcessor) {
-	defer func() {
-		if panicState := recover(); panicState != nil {
-			fmt.Printf("ERROR: Recovered from panic state:", panicState)
-		}
-	}()
+// configures a job added through Submit...() methods.
+type SubmitOption func(*submitConfig)
+
+// job configuration built by the submit options.
+type submitConfig struct {
+	priority *int  // nil means the job's own, see Prioritizer.
+	tenant string
+	key string
+	metadata map[string]string
+	ctx context.Context  // nil means none, see WithContext().
+	name string          // empty means the job's own, see JobProcessor.GetName().
+}
+
+
+// names the job other than by its own name. used by NewJob().
+func withJobName(name string) SubmitOption {
+	return func(sc *submitConfig) {
+		sc.name = name
+	}
+}
+
+
+// creates a new job for the worker-pool.
+func (pwp *WorkerPool) newJob(job JobProcessor, opts ...SubmitOption) Job {
+	sc := submitConfig{}
+	for _, opt := range opts {
+		opt(&sc)
+	}
+
+	priority := 0
+	if sc.priority != nil {
+		priority = *sc.priority
+	} else if p, ok := unwrapJob(job).(Prioritizer); ok {
+		priority = p.Priority()
+	}
+
+	name := sc.name
+	if name == "" {
+		name = job.GetName()
+	}
 
 	id := atomic.AddUint64(&pwp.jobcnt, 1)
-	j := Job {
+	now := time.Now()
+	return Job {
 		id: id,
+		name: name,
 		data: job,
+		handle: newJobHandle(id, name),
+		submittedAt: now,
+		priority: priority,
+		tenant: sc.tenant,
+		key: sc.key,
+		metadata: sc.metadata,
+		ctx: sc.ctx,
+		rec: &jobRecord{submittedAt: now},
+	}
+}
+
+
+/* *****************************************************************************
+Description : Adds a job to the worker-pool. Blocks until there's room in the job queue.
+
+Receiver    : *WorkerPool
+
+Implements  : NA
+
+Arguments   :
+1> job JobProcessor: Job to be executed.
+
+Return value: NA
+
+Additional note:
+Kept for compatibility. A job that can't be added, e.g., once the worker-pool is stopped, is
+dropped silently. Submit...() methods rather report why a job couldn't be added.
+***************************************************************************** */
+func (pwp *WorkerPool) AddJob(job JobProcessor) {
+	pwp.Submit(context.Background(), job)
+}
+
+
+/* *****************************************************************************
+Description : Adds a job to the worker-pool and returns a handle to await its execution status.
+
+Receiver    : *WorkerPool
+
+Implements  : NA
+
+Arguments   :
+1> job JobProcessor: Job to be executed.
+
+Return value:
+1> *JobHandle: Future of the newly added job.
+2> error: ErrPoolStopped if the worker-pool has been stopped.
+
+Additional note:
+Blocks until there's room in the job queue.
+***************************************************************************** */
+func (pwp *WorkerPool) AddJobWithHandle(job JobProcessor) (*JobHandle, error) {
+	return pwp.SubmitWithHandle(context.Background(), job)
+}
+
+
+/* *****************************************************************************
+Description : Adds a job to the worker-pool. Waits for room in the job queue until ctx is done.
+
+Receiver    : *WorkerPool
+
+Implements  : NA
+
+Arguments   :
+1> ctx context.Context: Bounds the wait for room in the job queue. The job itself isn't
+affected by its cancellation.
+2> job JobProcessor: Job to be executed.
+3> opts ...SubmitOption: Job options, e.g., WithPriority().
+
+Return value:
+1> error: ErrPoolStopped if the worker-pool has been stopped, ctx.Err() if ctx is done before
+there's room in the job queue. While waiting, ctx.Err() is wrapped along with ErrQueueFull, or
+ErrTenantQueueFull if it's the tenant's queue that's full.
+
+Additional note: NA
+***************************************************************************** */
+func (pwp *WorkerPool) Submit(ctx context.Context, job JobProcessor, opts ...SubmitOption) error {
+	_, err := pwp.SubmitWithHandle(ctx, job, opts...)
+	return err
+}
+
+
+/* *****************************************************************************
+Description : Same as Submit(), also returns a handle to await the job execution status.
+
+Receiver    : *WorkerPool
+
+Implements  : NA
+
+Arguments   :
+1> ctx context.Context: Bounds the wait for room in the job queue.
+2> job JobProcessor: Job to be executed.
+3> opts ...SubmitOption: Job options.
+
+Return value:
+1> *JobHandle: Future of the newly added job, nil if the job couldn't be added.
+2> error: Same as Submit().
+
+Additional note: NA
+***************************************************************************** */
+func (pwp *WorkerPool) SubmitWithHandle(ctx context.Context, job JobProcessor, opts ...SubmitOption) (*JobHandle, error) {
+	j := pwp.newJob(job, opts...)
+	if err := pwp.enqueue(ctx, j, true); err != nil {
+		return nil, err
+	}
+
+	return j.handle, nil
+}
+
+
+/* *****************************************************************************
+Description : Adds a job to the worker-pool only if there's room in the job queue right away.
+
+Receiver    : *WorkerPool
+
+Implements  : NA
+
+Arguments   :
+1> job JobProcessor: Job to be executed.
+2> opts ...SubmitOption: Job options.
+
+Return value:
+1> error: ErrQueueFull if the job queue is full, ErrTenantQueueFull if the tenant's queue is,
+ErrPoolStopped if the worker-pool has been stopped.
+
+Additional note: NA
+***************************************************************************** */
+func (pwp *WorkerPool) TrySubmit(job JobProcessor, opts ...SubmitOption) error {
+	return pwp.enqueue(context.Background(), pwp.newJob(job, opts...), false)
+}
+
+
+/* *****************************************************************************
+Description : Adds a job to the worker-pool. Waits for room in the job queue for at most d.
+
+Receiver    : *WorkerPool
+
+Implements  : NA
+
+Arguments   :
+1> job JobProcessor: Job to be executed.
+2> d time.Duration: Maximum wait for room in the job queue.
+3> opts ...SubmitOption: Job options.
+
+Return value:
+1> error: ErrQueueFull if the job queue is still full after d, ErrTenantQueueFull if it's rather
+the tenant's queue, ErrPoolStopped if the worker-pool has been stopped.
+
+Additional note: NA
+***************************************************************************** */
+func (pwp *WorkerPool) SubmitTimeout(job JobProcessor, d time.Duration, opts ...SubmitOption) error {
+	if d <= 0 {
+		return pwp.TrySubmit(job, opts...)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), d)
+	defer cancel()
+
+	err := pwp.enqueue(ctx, pwp.newJob(job, opts...), true)
+	switch {
+		case !errors.Is(err, context.DeadlineExceeded):
+			return err
+		case errors.Is(err, ErrTenantQueueFull):
+			return ErrTenantQueueFull
 	}
 
-	pwp.jobq <- j
+	return ErrQueueFull
 }
@@ -0,0 +1,129 @@
+/* *****************************************************************************
+Copyright (c) 2023, sameeroak1110 (sameeroak1110@gmail.com)
+BSD 3-Clause License.
+
+Package     : github.com/sameeroak1110/gowp
+Filename    : github.com/sameeroak1110/gowp/jobHandle.go
+File-type   : GoLang source code file
+
+Compiler/Runtime: go version go1.20.5 linux/amd64
+
+Version History
+Version     : 1.0
+Author      : Sameer Oak (sameeroak1110@gmail.com)
+
+Description :
+- JobHandle methods. A JobHandle is a future of a submitted job.
+***************************************************************************** */
+package gowp
+
+import (
+	"context"
+)
+
+
+func newJobHandle(id uint64, name string) *JobHandle {
+	return &JobHandle {
+		id: id,
+		name: name,
+		done: make(chan struct{}),
+	}
+}
+
+
+// resolves the handle. only the first call has any effect.
+func (h *JobHandle) complete(js JobStatus) {
+	if h == nil {
+		return
+	}
+
+	h.once.Do(func() {
+		h.status = js
+		close(h.done)
+	})
+}
+
+
+func (h *JobHandle) GetID() uint64 {
+	return h.id
+}
+
+
+func (h *JobHandle) GetName() string {
+	return h.name
+}
+
+
+// records the latest progress of the job.
+func (h *JobHandle) setProgress(p Progress) {
+	if h == nil {
+		return
+	}
+
+	h.pmu.Lock()
+	h.progress = &p
+	h.pmu.Unlock()
+}
+
+
+// Progress returns the latest progress reported by the job, false if it hasn't reported any.
+func (h *JobHandle) Progress() (Progress, bool) {
+	h.pmu.Lock()
+	defer h.pmu.Unlock()
+
+	if h.progress == nil {
+		return Progress{}, false
+	}
+
+	return *h.progress, true
+}
+
+
+// Done returns a channel that's closed once the job execution status is available.
+func (h *JobHandle) Done() <-chan struct{} {
+	return h.done
+}
+
+
+/* *****************************************************************************
+Description : Waits for the job to finish.
+
+Receiver    : *JobHandle
+
+Implements  : NA
+
+Arguments   :
+1> ctx context.Context: Bounds the wait. The job itself isn't affected by its cancellation.
+
+Return value:
+1> interface{}: Value returned by Process() method of the job.
+2> error: Error returned by Process() method of the job, or error returned by ProcessResult()
+method if the result is a JobResultProcessor, or ctx.Err() if ctx is done before the job finishes.
+
+Additional note: NA
+***************************************************************************** */
+func (h *JobHandle) Wait(ctx context.Context) (interface{}, error) {
+	select {
+		case <-h.done:
+			if h.status.err != nil {
+				return h.status.data, h.status.err
+			}
+			return h.status.data, h.status.rerr
+
+		case <-ctx.Done():
+			return nil, ctx.Err()
+	}
+}
+
+
+// Status returns the job execution status without waiting. The bool is false if the job
+// hasn't finished yet.
+func (h *JobHandle) Status() (JobStatus, bool) {
+	select {
+		case <-h.done:
+			return h.status, true
+
+		default:
+			return JobStatus{}, false
+	}
+}
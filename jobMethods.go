@@ -1,17 +1,9 @@
 package gowp
 
-import (
-	"sync/atomic"
-)
-
 
+// NewJob creates a job of the worker-pool named _name, built as the Submit...() methods do.
 func (pwp *WorkerPool) NewJob(_name string, _data JobProcessor) Job {
-	tmpID := atomic.AddUint64(&pwp.jobcnt, 1)
-	return Job {
-		id: tmpID,
-		name: _name,
-		data: _data,
-	}
+	return pwp.newJob(_data, withJobName(_name))
 }
 
 
@@ -28,3 +20,53 @@ func (job Job) GetName() string {
 func (job Job) GetData() JobProcessor {
 	return job.data
 }
+
+
+func (job Job) GetPriority() int {
+	return job.priority
+}
+
+
+func (job Job) GetTenant() string {
+	return job.tenant
+}
+
+
+func (job Job) GetKey() string {
+	return job.key
+}
+
+
+func (js JobStatus) GetJobID() uint64 {
+	return js.id
+}
+
+
+func (js JobStatus) GetJobName() string {
+	return js.name
+}
+
+
+func (js JobStatus) GetData() interface{} {
+	return js.data
+}
+
+
+func (js JobStatus) GetError() error {
+	return js.err
+}
+
+
+func (js JobStatus) GetAttempts() int {
+	return js.attempts
+}
+
+
+func (js JobStatus) GetResultData() interface{} {
+	return js.rdata
+}
+
+
+func (js JobStatus) GetResultError() error {
+	return js.rerr
+}
@@ -0,0 +1,157 @@
+package gowp
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestSubmitVariants(t *testing.T) {
+	type submitFunc func(*testPool, ...SubmitOption) error
+	submit := func(tp *testPool, opts ...SubmitOption) error {
+		ctx, cancel := context.WithTimeout(context.Background(), 10 * time.Millisecond)
+		defer cancel()
+		return tp.Submit(ctx, valueJob(nil), opts...)
+	}
+	trySubmit := func(tp *testPool, opts ...SubmitOption) error {
+		return tp.TrySubmit(valueJob(nil), opts...)
+	}
+	submitTimeout := func(tp *testPool, opts ...SubmitOption) error {
+		return tp.SubmitTimeout(valueJob(nil), 10 * time.Millisecond, opts...)
+	}
+
+	tests := []struct {
+		name string
+		submit submitFunc
+		tenant bool    // the tenant's queue is full rather than the job queue.
+		want []error   // errors the result is expected to wrap.
+		not error      // error the result isn't expected to wrap.
+	}{
+		{"submit, queue full", submit, false, []error{context.DeadlineExceeded, ErrQueueFull}, ErrTenantQueueFull},
+		{"submit, tenant full", submit, true, []error{context.DeadlineExceeded, ErrTenantQueueFull}, nil},
+		{"try submit, queue full", trySubmit, false, []error{ErrQueueFull}, ErrTenantQueueFull},
+		{"try submit, tenant full", trySubmit, true, []error{ErrTenantQueueFull}, nil},
+		{"submit timeout, queue full", submitTimeout, false, []error{ErrQueueFull}, context.DeadlineExceeded},
+		{"submit timeout, tenant full", submitTimeout, true, []error{ErrTenantQueueFull}, context.DeadlineExceeded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// not started, thus queued jobs stay queued.
+			tp := newPool(t, WithWorkers(1), WithQueueCapacity(2), WithResults(0),
+				WithFairQueue(FairQueueOptions{Limits: map[string]int{"a": 1}}))
+
+			var opts []SubmitOption
+			if tt.tenant {
+				opts = append(opts, WithTenant("a"))
+				if err := tt.submit(tp, opts...); err != nil {
+					t.Fatalf("first job of the tenant: %v", err)
+				}
+			} else {
+				for i := 0; i < 2; i++ {
+					if err := tt.submit(tp); err != nil {
+						t.Fatalf("job %d: %v", i, err)
+					}
+				}
+			}
+
+			err := tt.submit(tp, opts...)
+			for _, want := range tt.want {
+				if !errors.Is(err, want) {
+					t.Fatalf("%v, want %v", err, want)
+				}
+			}
+			if (tt.not != nil) && errors.Is(err, tt.not) {
+				t.Fatalf("%v, don't want %v", err, tt.not)
+			}
+		})
+	}
+}
+
+
+func TestSubmitWaitsForRoom(t *testing.T) {
+	tp := newPool(t, WithWorkers(1), WithQueueCapacity(1), WithResults(0))
+	if err := tp.TrySubmit(valueJob(1)); err != nil {
+		t.Fatalf("TrySubmit(): %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- tp.SubmitTimeout(valueJob(2), testWait)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	tp.start(t)
+
+	select {
+		case err := <-done:
+			if err != nil {
+				t.Fatalf("SubmitTimeout(): %v", err)
+			}
+		case <-time.After(testWait):
+			t.Fatal("SubmitTimeout() didn't return once there's room")
+	}
+}
+
+
+func TestSubmitStopped(t *testing.T) {
+	tp := newPool(t, WithWorkers(1), WithQueueCapacity(1), WithResults(0))
+	if err := tp.TrySubmit(valueJob(1)); err != nil {
+		t.Fatalf("TrySubmit(): %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- tp.Submit(context.Background(), valueJob(2))
+	}()
+	time.Sleep(10 * time.Millisecond)
+	tp.Stop()
+
+	select {
+		case err := <-done:
+			if !errors.Is(err, ErrPoolStopped) {
+				t.Fatalf("Submit() waiting for room: %v, want ErrPoolStopped", err)
+			}
+		case <-time.After(testWait):
+			t.Fatal("Submit() waiting for room didn't return once the worker-pool is stopped")
+	}
+
+	for name, err := range map[string]error{
+		"Submit": tp.Submit(context.Background(), valueJob(3)),
+		"TrySubmit": tp.TrySubmit(valueJob(3)),
+		"SubmitTimeout": tp.SubmitTimeout(valueJob(3), time.Millisecond),
+	} {
+		if !errors.Is(err, ErrPoolStopped) {
+			t.Errorf("%s(): %v, want ErrPoolStopped", name, err)
+		}
+	}
+}
+
+
+func TestAddJob(t *testing.T) {
+	tp := startPool(t, WithWorkers(1))
+	tp.AddJob(valueJob(1))
+	waitFor(t, "job added", func() bool {
+		return tp.Stats().Succeeded == 1
+	})
+	tp.Stop()
+
+	// a job that can't be added is dropped, nothing's printed.
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	tp.AddJob(valueJob(2))
+	os.Stdout = stdout
+	w.Close()
+
+	out, _ := io.ReadAll(r)
+	r.Close()
+	if len(out) != 0 {
+		t.Fatalf("AddJob() printed %q", out)
+	}
+}
@@ -0,0 +1,189 @@
+/* *****************************************************************************
+Copyright (c) 2023, sameeroak1110 (sameeroak1110@gmail.com)
+BSD 3-Clause License.
+
+Package     : github.com/sameeroak1110/gowp
+Filename    : github.com/sameeroak1110/gowp/keyed.go
+File-type   : GoLang source code file
+
+Compiler/Runtime: go version go1.20.5 linux/amd64
+
+Version History
+Version     : 1.0
+Author      : Sameer Oak (sameeroak1110@gmail.com)
+
+Description :
+- Ordered per-key execution. Jobs submitted with the same key, see WithKey(), run one at a time
+in the order they're popped from the job queue. Jobs of different keys run in parallel.
+- Each busy key has a mailbox. A job popped while another job of its key is running is parked in
+the key's mailbox, and its worker is handed back right away. Thus, a busy key doesn't block the
+jobs of the other keys.
+- Once a keyed job is done, its worker runs the next parked job of the key, if any. Once the
+worker-pool is stopped, parked jobs stay in the mailbox, and they're run once it's started again.
+Shutdown() takes them along with the queued jobs.
+***************************************************************************** */
+package gowp
+
+import (
+	"sort"
+)
+
+// WithKey sets the key of the job. Jobs of the same key never run concurrently and run in the
+// order they're popped from the job queue. That's the order they're submitted in, unless the job
+// queue is a priority or a fair queue, which pops them as per their priority or tenant instead.
+// Default is no key.
+func WithKey(key string) SubmitOption {
+	return func(sc *submitConfig) {
+		sc.key = key
+	}
+}
+
+
+// claims the job's key for it. false if another job of the key is running, the job is parked in
+// the key's mailbox then.
+func (pwp *WorkerPool) claimKey(j Job) bool {
+	if j.key == "" {
+		return true
+	}
+
+	pwp.kmu.Lock()
+	defer pwp.kmu.Unlock()
+
+	if box, busy := pwp.kboxes[j.key]; busy {
+		box.push(j)
+		return false
+	}
+	pwp.kboxes[j.key] = &fifoQueue{}
+
+	return true
+}
+
+
+// pops the next parked job of the key. the key is released if there isn't one.
+func (pwp *WorkerPool) nextKeyed(key string) (Job, bool) {
+	pwp.kmu.Lock()
+	defer pwp.kmu.Unlock()
+
+	box, busy := pwp.kboxes[key]
+	if !busy {
+		return Job{}, false
+	}
+
+	j, ok := box.pop()
+	if !ok {
+		delete(pwp.kboxes, key)
+	}
+
+	return j, ok
+}
+
+
+// leaves the parked jobs of the key in its mailbox once the worker-pool is stopped, they're run
+// once it's started again, see resumeKeyed(). the key is released if there aren't any.
+func (pwp *WorkerPool) leaveKeyed(key string) {
+	pwp.kmu.Lock()
+	defer pwp.kmu.Unlock()
+
+	if box, busy := pwp.kboxes[key]; busy && (box.len() == 0) {
+		delete(pwp.kboxes, key)
+	}
+
+	return
+}
+
+
+// returns the keys whose parked jobs have been left in their mailbox by the previous run of
+// Start(), in the order of their next job. they stay claimed, their jobs are popped through
+// nextKeyed(). invoked by Start() before any job is dispatched, thus no job of any key is running.
+func (pwp *WorkerPool) resumeKeyed() []string {
+	pwp.kmu.Lock()
+	defer pwp.kmu.Unlock()
+
+	var keys []string
+	next := make(map[string]uint64, len(pwp.kboxes))
+	for key, box := range pwp.kboxes {
+		j, ok := box.peek()
+		if !ok {
+			delete(pwp.kboxes, key)
+			continue
+		}
+		keys = append(keys, key)
+		next[key] = j.id
+	}
+
+	sort.Slice(keys, func(i, k int) bool {
+		return next[keys[i]] < next[keys[k]]
+	})
+
+	return keys
+}
+
+
+// removes and returns all the parked jobs, invoked by Shutdown(). keys of the running jobs stay
+// claimed until they finish.
+func (pwp *WorkerPool) takeKeyed() []Job {
+	pwp.kmu.Lock()
+	defer pwp.kmu.Unlock()
+
+	var jobs []Job
+	for _, box := range pwp.kboxes {
+		jobs = append(jobs, box.removeWhere(func(Job) bool {
+			return true
+		})...)
+	}
+
+	sort.Slice(jobs, func(i, k int) bool {
+		return jobs[i].id < jobs[k].id
+	})
+
+	return jobs
+}
+
+
+// releases the key, and resolves the handles of its parked jobs with err. invoked once the
+// worker-pool context is done as the parked jobs aren't going to be run.
+func (pwp *WorkerPool) dropKeyed(key string, err error) {
+	pwp.kmu.Lock()
+	box, busy := pwp.kboxes[key]
+	delete(pwp.kboxes, key)
+	pwp.kmu.Unlock()
+
+	for busy {
+		j, ok := box.pop()
+		if !ok {
+			break
+		}
+		pwp.resolve(j, JobStatus{id: j.id, name: j.name, err: err})
+	}
+
+	return
+}
+
+
+/* *****************************************************************************
+Description : Returns the no. of jobs parked behind the running job of the key.
+
+Receiver    : *WorkerPool
+
+Implements  : NA
+
+Arguments   :
+1> key string: Key of the jobs.
+
+Return value:
+1> int: No. of parked jobs. -1 if no job of the key is running.
+
+Additional note:
+Jobs of the key still in the job queue aren't counted.
+***************************************************************************** */
+func (pwp *WorkerPool) KeyBacklog(key string) int {
+	pwp.kmu.Lock()
+	defer pwp.kmu.Unlock()
+
+	box, busy := pwp.kboxes[key]
+	if !busy {
+		return -1
+	}
+
+	return box.len()
+}
@@ -0,0 +1,165 @@
+package gowp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestKeyedOrder(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+		keys int
+		jobs int
+	}{
+		{"single key", []Option{WithWorkers(4)}, 1, 50},
+		{"several keys", []Option{WithWorkers(4)}, 5, 30},
+		{"one worker", []Option{WithWorkers(1)}, 3, 20},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tp := startPool(t, tt.opts...)
+
+			var mu sync.Mutex
+			order := make(map[string][]int)
+			running := make(map[string]*int32)
+			for k := 0; k < tt.keys; k++ {
+				running[fmt.Sprint(k)] = new(int32)
+			}
+
+			var hs []*JobHandle
+			for i := 0; i < tt.jobs; i++ {
+				for k := 0; k < tt.keys; k++ {
+					key, i := fmt.Sprint(k), i
+					job := Func("keyed", func(context.Context) (interface{}, error) {
+						if n := atomic.AddInt32(running[key], 1); n != 1 {
+							return nil, fmt.Errorf("%d jobs of key %s running", n, key)
+						}
+						defer atomic.AddInt32(running[key], -1)
+
+						mu.Lock()
+						order[key] = append(order[key], i)
+						mu.Unlock()
+						time.Sleep(100 * time.Microsecond)
+						return nil, nil
+					})
+					h, err := tp.SubmitWithHandle(context.Background(), job, WithKey(key))
+					if err != nil {
+						t.Fatalf("SubmitWithHandle(): %v", err)
+					}
+					hs = append(hs, h)
+				}
+			}
+
+			for _, h := range hs {
+				if _, err := wait(t, h); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			for key, got := range order {
+				for i := range got {
+					if got[i] != i {
+						t.Fatalf("key %s ran out of order: %v", key, got)
+					}
+				}
+			}
+		})
+	}
+}
+
+
+// submits a blocking job of the key followed by n jobs that get parked behind it.
+func parkJobs(t *testing.T, tp *testPool, key string, n int, ran *int32) (release chan struct{}, hs []*JobHandle) {
+	t.Helper()
+
+	started := make(chan struct{})
+	release = make(chan struct{})
+	if err := tp.Submit(context.Background(), blockJob(started, release), WithKey(key)); err != nil {
+		t.Fatalf("Submit(): %v", err)
+	}
+	<-started
+
+	for i := 0; i < n; i++ {
+		job := Func("parked", func(context.Context) (interface{}, error) {
+			atomic.AddInt32(ran, 1)
+			return nil, nil
+		})
+		h, err := tp.SubmitWithHandle(context.Background(), job, WithKey(key))
+		if err != nil {
+			t.Fatalf("SubmitWithHandle(): %v", err)
+		}
+		hs = append(hs, h)
+	}
+	waitFor(t, "jobs parked", func() bool {
+		return tp.KeyBacklog(key) == n
+	})
+
+	return release, hs
+}
+
+
+func TestKeyedStop(t *testing.T) {
+	tp := startPool(t, WithWorkers(2))
+
+	var ran int32
+	release, hs := parkJobs(t, tp, "k", 3, &ran)
+	tp.Stop()
+	close(release)
+	tp.waitStart(t)
+
+	if n := atomic.LoadInt32(&ran); n != 0 {
+		t.Fatalf("%d parked jobs ran after Stop()", n)
+	}
+	if n := tp.KeyBacklog("k"); n != 3 {
+		t.Fatalf("backlog %d after Stop(), want 3", n)
+	}
+
+	tp.start(t)
+	for _, h := range hs {
+		if _, err := wait(t, h); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := atomic.LoadInt32(&ran); n != 3 {
+		t.Fatalf("%d parked jobs ran after restart, want 3", n)
+	}
+	waitFor(t, "key released", func() bool {
+		return tp.KeyBacklog("k") == -1
+	})
+}
+
+
+func TestKeyedShutdown(t *testing.T) {
+	tp := startPool(t, WithWorkers(2))
+
+	var ran int32
+	release, hs := parkJobs(t, tp, "k", 3, &ran)
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20 * time.Millisecond)
+	defer cancel()
+	jobs, err := tp.Shutdown(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Shutdown(): %v, want context.DeadlineExceeded", err)
+	}
+	if len(jobs) != 3 {
+		t.Fatalf("Shutdown() returned %d jobs, want the 3 parked ones", len(jobs))
+	}
+	for _, h := range hs {
+		if _, err := wait(t, h); !errors.Is(err, ErrPoolStopped) {
+			t.Fatalf("parked job: %v, want ErrPoolStopped", err)
+		}
+	}
+	if n := atomic.LoadInt32(&ran); n != 0 {
+		t.Fatalf("%d parked jobs ran", n)
+	}
+}
@@ -0,0 +1,463 @@
+/* *****************************************************************************
+Copyright (c) 2023, sameeroak1110 (sameeroak1110@gmail.com)
+BSD 3-Clause License.
+
+Package     : github.com/sameeroak1110/gowp
+Filename    : github.com/sameeroak1110/gowp/mapper.go
+File-type   : GoLang source code file
+
+Compiler/Runtime: go version go1.20.5 linux/amd64
+
+Version History
+Version     : 1.0
+Author      : Sameer Oak (sameeroak1110@gmail.com)
+
+Description :
+- Parallel helpers that run a function over the items of a slice, a channel, or an iterator with
+bounded concurrency: Map(), MapOrdered(), MapSeq(), ForEach(), and MapChan().
+- Each item is a job of a worker-pool, either the one passed through OnPool(), or a transient one
+created for the call and shut down once it returns. Thus, the worker-pool's timeouts, retries,
+and panic recovery apply to each item.
+- Errors of all the items are aggregated through errors.Join(). With StopOnError(), the items not
+yet started once an item fails are skipped.
+- Iterators are push iterators, func(yield func(In) bool), the shape of iter.Seq. Since the module
+is on go1.20, iter package itself isn't referred to.
+***************************************************************************** */
+package gowp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// value of a skipped item. it isn't an error, so that the worker-pool neither retries nor
+// dead-letters the item.
+type mapSkipped struct{}
+
+// configures a Map...() or ForEach() call.
+type MapOption func(*mapConfig)
+
+// configuration built by the map options.
+type mapConfig struct {
+	pwp *WorkerPool
+	workers int32
+	stopOnError bool
+}
+
+// MapResult is the outcome of an item.
+type MapResult[Out any] struct {
+	Index int    // position of the item in the input.
+	Value Out
+	Err   error
+}
+
+
+// OnPool runs the items on pwp rather than on a transient worker-pool. pwp must be started for
+// the items to run. If it's stopped during the run, the items not yet running fail with ErrPoolStopped.
+func OnPool(pwp *WorkerPool) MapOption {
+	return func(cfg *mapConfig) {
+		cfg.pwp = pwp
+	}
+}
+
+
+// WithConcurrency sets the no. of workers of the transient worker-pool. Default is 10.
+func WithConcurrency(n int32) MapOption {
+	return func(cfg *mapConfig) {
+		cfg.workers = n
+	}
+}
+
+
+// StopOnError skips the items not yet started once an item fails.
+func StopOnError() MapOption {
+	return func(cfg *mapConfig) {
+		cfg.stopOnError = true
+	}
+}
+
+
+/* *****************************************************************************
+Description : Same as mapRun(), the errors are joined.
+
+Receiver    : NA
+
+Implements  : NA
+
+Arguments   : Same as mapRun().
+
+Return value:
+1> error: Errors of the items joined with the error of the run itself, see mapRun().
+
+Additional note: NA
+***************************************************************************** */
+func mapJoin[In, Out any](ctx context.Context, feed func(context.Context, func(In) bool),
+	fn func(context.Context, In) (Out, error), opts []MapOption, emit func(MapResult[Out])) error {
+	errs, err := mapRun(ctx, feed, fn, opts, emit)
+	return errors.Join(append(errs, err)...)
+}
+
+
+/* *****************************************************************************
+Description : Runs fn over the items fed by feed, on a worker-pool. Common to all the helpers.
+
+Receiver    : NA
+
+Implements  : NA
+
+Arguments   :
+1> ctx context.Context: Bounds the whole run, passed on to fn.
+2> feed func(context.Context, func(In) bool): Yields the items until yield returns false.
+3> fn func(context.Context, In) (Out, error): Function run on each item.
+4> opts []MapOption: Map options.
+5> emit func(MapResult[Out]): Invoked once for each item that's run. It may be invoked
+concurrently, for different items, and may block.
+
+Return value:
+1> []error: Errors of the items.
+2> error: Error of the run itself: invalid arguments, the error of submitting an item, or ctx.Err().
+
+Additional note: NA
+***************************************************************************** */
+func mapRun[In, Out any](ctx context.Context, feed func(context.Context, func(In) bool),
+	fn func(context.Context, In) (Out, error), opts []MapOption, emit func(MapResult[Out])) ([]error, error) {
+	if fn == nil {
+		return nil, fmt.Errorf("%w: nil map function", ErrInvalidOption)
+	}
+
+	cfg := mapConfig{}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	pwp := cfg.pwp
+	if pwp == nil {
+		workers := cfg.workers
+		if workers < 1 {
+			workers = minWPSize
+		}
+
+		// queue as long as the no. of workers, feeding waits for the workers rather than
+		// loading all the items at once.
+		tmp, err := New(ctx, WithWorkers(workers), WithQueueCapacity(int(workers)), WithName("gowp.map"))
+		if err != nil {
+			return nil, err
+		}
+		go tmp.Start(ctx, nil)
+		defer func() {
+			tmp.Shutdown(context.Background())  // all the items have finished, nothing to wait for.
+			tmp.GetCancelFunc()()
+		}()
+		pwp = tmp
+	}
+
+	mctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var errs []error
+	var emu sync.Mutex
+	record := func(r MapResult[Out]) {
+		if r.Err != nil {
+			emu.Lock()
+			errs = append(errs, r.Err)
+			emu.Unlock()
+			if cfg.stopOnError {
+				cancel()
+			}
+		}
+		emit(r)  // outside emu, a blocked emit doesn't hold back the other items.
+	}
+
+	var wg sync.WaitGroup
+	var serr error
+	idx := 0
+	feed(mctx, func(in In) bool {
+		if mctx.Err() != nil {
+			return false
+		}
+
+		i := idx
+		idx++
+		job := Func("gowp.map", func(jctx context.Context) (interface{}, error) {
+			if mctx.Err() != nil {
+				return mapSkipped{}, nil
+			}
+
+			// the item is cancelled along with the worker-pool context as well as mctx.
+			jctx, jcancel := context.WithCancel(jctx)
+			defer jcancel()
+			stop := make(chan struct{})
+			defer close(stop)
+			go func() {
+				select {
+					case <-mctx.Done():
+						jcancel()
+					case <-stop:
+				}
+			}()
+
+			return fn(jctx, in)
+		})
+
+		h, err := pwp.SubmitWithHandle(mctx, job)
+		if err != nil {
+			if mctx.Err() == nil {
+				serr = err
+			}
+			return false
+		}
+
+		halted := pwp.haltSignal()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			select {
+				case <-h.Done():
+
+				case <-pwp.GetContext().Done():  // item isn't going to be run.
+					record(MapResult[Out]{Index: i, Err: pwp.GetContext().Err()})
+					return
+
+				case <-halted:
+					// a waiting item would be run only if the worker-pool is started again, it's cancelled
+					// rather than having the run hang. a running item is waited for.
+					pwp.cancelWaiting(h.GetID(), ErrPoolStopped)
+					<-h.Done()
+			}
+
+			v, err := h.Wait(context.Background())
+			if _, ok := v.(mapSkipped); ok {
+				return
+			}
+			out, _ := v.(Out)
+			record(MapResult[Out]{Index: i, Value: out, Err: err})
+		}()
+
+		return true
+	})
+	wg.Wait()
+
+	if serr != nil {
+		return errs, serr
+	}
+
+	return errs, ctx.Err()
+}
+
+
+// feeds the items of a slice.
+func sliceFeed[In any](items []In) func(context.Context, func(In) bool) {
+	return func(_ context.Context, yield func(In) bool) {
+		for _, in := range items {
+			if !yield(in) {
+				return
+			}
+		}
+	}
+}
+
+
+/* *****************************************************************************
+Description : Runs fn over the items in parallel and collects the outputs as they finish.
+
+Receiver    : NA
+
+Implements  : NA
+
+Arguments   :
+1> ctx context.Context: Bounds the run, passed on to fn.
+2> items []In: Inputs.
+3> fn func(context.Context, In) (Out, error): Function run on each item.
+4> opts ...MapOption: OnPool(), WithConcurrency(), StopOnError().
+
+Return value:
+1> []Out: Outputs of the successful items, in the order they finished.
+2> error: Errors of the failed items, and ctx.Err() if it's done, joined. nil if all succeed.
+
+Additional note:
+MapOrdered() rather returns the outputs in the order of the items.
+***************************************************************************** */
+func Map[In, Out any](ctx context.Context, items []In, fn func(context.Context, In) (Out, error), opts ...MapOption) ([]Out, error) {
+	var mu sync.Mutex
+	outs := make([]Out, 0, len(items))
+	err := mapJoin(ctx, sliceFeed(items), fn, opts, func(r MapResult[Out]) {
+		if r.Err == nil {
+			mu.Lock()
+			outs = append(outs, r.Value)
+			mu.Unlock()
+		}
+	})
+
+	return outs, err
+}
+
+
+/* *****************************************************************************
+Description : Same as Map(), the outputs are rather in the order of the items.
+
+Receiver    : NA
+
+Implements  : NA
+
+Arguments   : Same as Map().
+
+Return value:
+1> []Out: Outputs, as many as the items. Output of an item that failed, or was skipped, is the
+zero value.
+2> error: Same as Map().
+
+Additional note: NA
+***************************************************************************** */
+func MapOrdered[In, Out any](ctx context.Context, items []In, fn func(context.Context, In) (Out, error), opts ...MapOption) ([]Out, error) {
+	outs := make([]Out, len(items))
+	err := mapJoin(ctx, sliceFeed(items), fn, opts, func(r MapResult[Out]) {
+		outs[r.Index] = r.Value  // each item has its own element, no lock is needed.
+	})
+
+	return outs, err
+}
+
+
+/* *****************************************************************************
+Description : Same as MapOrdered(), the items are rather yielded by an iterator.
+
+Receiver    : NA
+
+Implements  : NA
+
+Arguments   :
+1> ctx context.Context: Bounds the run, passed on to fn.
+2> seq func(yield func(In) bool): Push iterator of the items, an iter.Seq[In] is accepted as well.
+3> fn func(context.Context, In) (Out, error): Function run on each item.
+4> opts ...MapOption: Same as Map().
+
+Return value:
+1> []Out: Outputs in the order of the items, one for each item taken from the iterator. Output of
+an item that failed, or was skipped, is the zero value.
+2> error: Same as Map().
+
+Additional note:
+All the items are taken, thus there are as many outputs as items, unless the run stops early:
+ctx is done, an item fails with StopOnError(), or an item can't be submitted. The iterator isn't
+drained then, the outputs end at the last item taken.
+***************************************************************************** */
+func MapSeq[In, Out any](ctx context.Context, seq func(yield func(In) bool), fn func(context.Context, In) (Out, error), opts ...MapOption) ([]Out, error) {
+	var mu sync.Mutex
+	var results []MapResult[Out]
+	taken := 0
+	feed := func(_ context.Context, yield func(In) bool) {
+		seq(func(in In) bool {
+			if !yield(in) {
+				return false
+			}
+			taken++
+			return true
+		})
+	}
+	err := mapJoin(ctx, feed, fn, opts, func(r MapResult[Out]) {
+		mu.Lock()
+		results = append(results, r)
+		mu.Unlock()
+	})
+
+	// mapJoin() has returned, thus results and taken aren't changed anymore.
+	outs := make([]Out, taken)
+	for _, r := range results {
+		outs[r.Index] = r.Value
+	}
+
+	return outs, err
+}
+
+
+/* *****************************************************************************
+Description : Runs fn over the items in parallel.
+
+Receiver    : NA
+
+Implements  : NA
+
+Arguments   :
+1> ctx context.Context: Bounds the run, passed on to fn.
+2> items []In: Inputs.
+3> fn func(context.Context, In) error: Function run on each item.
+4> opts ...MapOption: Same as Map().
+
+Return value:
+1> error: Same as Map().
+
+Additional note: NA
+***************************************************************************** */
+func ForEach[In any](ctx context.Context, items []In, fn func(context.Context, In) error, opts ...MapOption) error {
+	if fn == nil {
+		return fmt.Errorf("%w: nil map function", ErrInvalidOption)
+	}
+
+	return mapJoin(ctx, sliceFeed(items), func(ctx context.Context, in In) (struct{}, error) {
+		return struct{}{}, fn(ctx, in)
+	}, opts, func(MapResult[struct{}]) {})
+}
+
+
+/* *****************************************************************************
+Description : Runs fn over the items received from a channel, in parallel, and sends the results
+on the returned channel as they finish.
+
+Receiver    : NA
+
+Implements  : NA
+
+Arguments   :
+1> ctx context.Context: Bounds the run, passed on to fn.
+2> in <-chan In: Inputs. Items are received until it's closed.
+3> fn func(context.Context, In) (Out, error): Function run on each item.
+4> opts ...MapOption: Same as Map().
+
+Return value:
+1> <-chan MapResult[Out]: Result of each item. Closed once in is closed and all its items have
+finished, or ctx is done, or an item fails with StopOnError(). A final result with Index -1
+carries the error of the run itself, e.g., ctx.Err(), if any, see the note below.
+
+Additional note:
+The results must be received, the items wait for room in the channel otherwise. Once ctx is done,
+results the receiver isn't ready for, including the final one, are dropped rather than waited for.
+Thus, the receiver may stop receiving once it cancels ctx.
+***************************************************************************** */
+func MapChan[In, Out any](ctx context.Context, in <-chan In, fn func(context.Context, In) (Out, error), opts ...MapOption) <-chan MapResult[Out] {
+	results := make(chan MapResult[Out])
+	feed := func(mctx context.Context, yield func(In) bool) {
+		for {
+			select {
+				case item, ok := <-in:
+					if !ok || !yield(item) {
+						return
+					}
+
+				case <-mctx.Done():
+					return
+			}
+		}
+	}
+
+	send := func(r MapResult[Out]) {
+		select {
+			case results <- r:
+			case <-ctx.Done():  // the receiver may have gone away.
+		}
+	}
+
+	go func() {
+		defer close(results)
+
+		// errors of the items are sent along with their results.
+		_, err := mapRun(ctx, feed, fn, opts, send)
+		if err != nil {
+			send(MapResult[Out]{Index: -1, Err: err})
+		}
+	}()
+
+	return results
+}
@@ -0,0 +1,277 @@
+package gowp
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sort"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// doubles n, fails for the negative ones.
+func double(_ context.Context, n int) (int, error) {
+	if n < 0 {
+		return 0, errTest
+	}
+	return 2 * n, nil
+}
+
+
+// push iterator of the items.
+func seqOf(items []int) func(func(int) bool) {
+	return func(yield func(int) bool) {
+		for _, n := range items {
+			if !yield(n) {
+				return
+			}
+		}
+	}
+}
+
+
+func TestMapHelpers(t *testing.T) {
+	tests := []struct {
+		name string
+		items []int
+		ordered []int  // expected outputs of MapOrdered() and MapSeq().
+		fail bool
+	}{
+		{"empty", nil, []int{}, false},
+		{"all succeed", []int{1, 2, 3, 4, 5}, []int{2, 4, 6, 8, 10}, false},
+		{"some fail", []int{1, -1, 3, -2}, []int{2, 0, 6, 0}, true},
+		{"last fails", []int{1, 2, -3}, []int{2, 4, 0}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			check := func(what string, err error) {
+				t.Helper()
+				if tt.fail != errors.Is(err, errTest) {
+					t.Fatalf("%s(): %v, want failure %t", what, err, tt.fail)
+				}
+			}
+
+			outs, err := Map(ctx, tt.items, double, WithConcurrency(3))
+			check("Map", err)
+			sort.Ints(outs)
+			var want []int
+			for _, n := range tt.ordered {
+				if n != 0 {
+					want = append(want, n)
+				}
+			}
+			sort.Ints(want)
+			if !equalInts(outs, want) {
+				t.Fatalf("Map() = %v, want %v in any order", outs, want)
+			}
+
+			outs, err = MapOrdered(ctx, tt.items, double, WithConcurrency(3))
+			check("MapOrdered", err)
+			if !equalInts(outs, tt.ordered) {
+				t.Fatalf("MapOrdered() = %v, want %v", outs, tt.ordered)
+			}
+
+			outs, err = MapSeq(ctx, seqOf(tt.items), double, WithConcurrency(3))
+			check("MapSeq", err)
+			if !equalInts(outs, tt.ordered) {
+				t.Fatalf("MapSeq() = %v, want %v", outs, tt.ordered)
+			}
+
+			var sum int64
+			err = ForEach(ctx, tt.items, func(_ context.Context, n int) error {
+				if n < 0 {
+					return errTest
+				}
+				atomic.AddInt64(&sum, int64(n))
+				return nil
+			}, WithConcurrency(3))
+			check("ForEach", err)
+			var wantSum int64
+			for _, n := range tt.ordered {
+				wantSum += int64(n / 2)
+			}
+			if sum != wantSum {
+				t.Fatalf("ForEach() summed up %d, want %d", sum, wantSum)
+			}
+		})
+	}
+}
+
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+
+func TestMapSeqStopOnError(t *testing.T) {
+	items := []int{1, 2, -1, 4, 5, 6, 7, 8, 9, 10}
+	var taken int32
+	seq := func(yield func(int) bool) {
+		for _, n := range items {
+			if !yield(n) {
+				return
+			}
+			atomic.AddInt32(&taken, 1)
+		}
+	}
+	slow := func(ctx context.Context, n int) (int, error) {
+		if n < 0 {
+			return 0, errTest
+		}
+		time.Sleep(5 * time.Millisecond)
+		return n, nil
+	}
+
+	outs, err := MapSeq(context.Background(), seq, slow, WithConcurrency(1), StopOnError())
+	if !errors.Is(err, errTest) {
+		t.Fatalf("MapSeq(): %v, want the failure", err)
+	}
+	if n := int(atomic.LoadInt32(&taken)); len(outs) != n {
+		t.Fatalf("%d outputs, want one for each of the %d items taken", len(outs), n)
+	}
+	if len(outs) >= len(items) {
+		t.Fatalf("%d outputs, want the run stopped early", len(outs))
+	}
+	if (outs[0] != 1) || (outs[1] != 2) {
+		t.Fatalf("MapSeq() = %v, want the items before the failure", outs)
+	}
+}
+
+
+func TestMapOnPool(t *testing.T) {
+	tp := startPool(t, WithWorkers(2))
+
+	outs, err := MapOrdered(context.Background(), []int{1, 2, 3}, double, OnPool(tp.WorkerPool))
+	if (err != nil) || !equalInts(outs, []int{2, 4, 6}) {
+		t.Fatalf("MapOrdered() = %v, %v", outs, err)
+	}
+	if st := tp.Stats(); st.Succeeded != 3 {
+		t.Fatalf("%d jobs succeeded on the worker-pool, want 3", st.Succeeded)
+	}
+
+	if _, err := Map[int, int](context.Background(), []int{1}, nil); !errors.Is(err, ErrInvalidOption) {
+		t.Fatalf("Map() with a nil function: %v, want ErrInvalidOption", err)
+	}
+	if err := ForEach[int](context.Background(), []int{1}, nil); !errors.Is(err, ErrInvalidOption) {
+		t.Fatalf("ForEach() with a nil function: %v, want ErrInvalidOption", err)
+	}
+}
+
+
+func TestMapOnStoppedPool(t *testing.T) {
+	tp := startPool(t, WithWorkers(1))
+
+	started, release := make(chan struct{}), make(chan struct{})
+	var once atomic.Bool
+	fn := func(ctx context.Context, n int) (int, error) {
+		if once.CompareAndSwap(false, true) {
+			close(started)
+			<-release
+		}
+		return 2 * n, nil
+	}
+
+	type mapped struct {
+		outs []int
+		err error
+	}
+	done := make(chan mapped, 1)
+	go func() {
+		outs, err := Map(context.Background(), []int{1, 2, 3, 4, 5}, fn, OnPool(tp.WorkerPool))
+		done <- mapped{outs, err}
+	}()
+	<-started
+	waitFor(t, "items queued", func() bool {
+		return tp.Stats().Queued == 4
+	})
+	tp.Stop()
+	close(release)  // the running item finishes, the queued ones aren't run.
+
+	select {
+		case m := <-done:
+			if !errors.Is(m.err, ErrPoolStopped) || !equalInts(m.outs, []int{2}) {
+				t.Fatalf("Map() = %v, %v, want [2] and ErrPoolStopped", m.outs, m.err)
+			}
+		case <-time.After(testWait):
+			t.Fatal("Map() didn't return once the worker-pool is stopped")
+	}
+}
+
+
+func TestMapChan(t *testing.T) {
+	in := make(chan int)
+	go func() {
+		defer close(in)
+		for n := -2; n <= 5; n++ {
+			in <- n
+		}
+	}()
+
+	got := make(map[int]MapResult[int])
+	for r := range MapChan(context.Background(), in, double, WithConcurrency(3)) {
+		if _, ok := got[r.Index]; ok {
+			t.Fatalf("item %d sent twice", r.Index)
+		}
+		got[r.Index] = r
+	}
+	if len(got) != 8 {
+		t.Fatalf("%d results, want 8", len(got))
+	}
+	for i := 0; i < 8; i++ {
+		r := got[i]
+		n := i - 2
+		if (n < 0) != (r.Err != nil) || ((n >= 0) && (r.Value != 2 * n)) {
+			t.Fatalf("item %d: %+v", i, r)
+		}
+	}
+}
+
+
+func TestMapChanCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	in := make(chan int)  // never closed, the run ends with ctx.
+	results := MapChan(ctx, in, double)
+
+	in <- 1
+	if r := <-results; (r.Index != 0) || (r.Value != 2) {
+		t.Fatalf("first result %+v", r)
+	}
+	cancel()
+
+	// the final result may be dropped as ctx is done.
+	for r := range results {
+		if (r.Index != -1) || !errors.Is(r.Err, context.Canceled) {
+			t.Fatalf("result %+v once cancelled, want none but the context error", r)
+		}
+	}
+}
+
+
+func TestMapChanAbandoned(t *testing.T) {
+	base := runtime.NumGoroutine()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	in := make(chan int, 4)
+	for n := 1; n <= 4; n++ {
+		in <- n
+	}
+	close(in)
+	MapChan(ctx, in, double, WithConcurrency(2))
+
+	// the receiver cancels ctx and receives nothing, the run, and its transient worker-pool, end anyway.
+	cancel()
+	waitFor(t, "the run to end", func() bool {
+		return runtime.NumGoroutine() <= base
+	})
+}
@@ -0,0 +1,362 @@
+/* *****************************************************************************
+Copyright (c) 2023, sameeroak1110 (sameeroak1110@gmail.com)
+BSD 3-Clause License.
+
+Package     : github.com/sameeroak1110/gowp
+Filename    : github.com/sameeroak1110/gowp/options.go
+File-type   : GoLang source code file
+
+Compiler/Runtime: go version go1.20.5 linux/amd64
+
+Version History
+Version     : 1.0
+Author      : Sameer Oak (sameeroak1110@gmail.com)
+
+Description :
+- Functional options of New().
+- Each option validates its argument. New() returns the first error rather than clamping the
+invalid values.
+***************************************************************************** */
+package gowp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+var ErrInvalidOption = errors.New("gowp: invalid worker-pool option")
+
+// configures a worker-pool created through New().
+type Option func(*poolConfig) error
+
+// worker-pool configuration built by the options.
+type poolConfig struct {
+	cancelFunc context.CancelFunc
+	wpsize int32               // 0 means default.
+	qcap int                   // 0 means default.
+	unbounded bool             // true if the job queue is unbounded.
+	priority bool              // true if the job queue is a priority queue.
+	aging time.Duration        // priority aging interval of the priority queue.
+	fair *FairQueueOptions     // non-nil if the job queue is a fair queue.
+	name string
+	smsg string
+	cmsg string
+	opts WorkerPoolOptions
+}
+
+
+// WithWorkers sets the no. of workers. Default is 10.
+func WithWorkers(n int32) Option {
+	return func(cfg *poolConfig) error {
+		if n < 1 {
+			return fmt.Errorf("%w: %d workers, must be at least 1", ErrInvalidSize, n)
+		}
+		cfg.wpsize = n
+		return nil
+	}
+}
+
+
+// WithQueueCapacity sets the capacity of the job queue. Default is 100 times the no. of workers.
+func WithQueueCapacity(m int) Option {
+	return func(cfg *poolConfig) error {
+		if (m < 1) || (m > math.MaxInt32) {
+			return fmt.Errorf("%w: job queue capacity %d, must be in between 1 and %d", ErrInvalidOption, m, math.MaxInt32)
+		}
+		cfg.qcap = m
+		cfg.unbounded = false
+		return nil
+	}
+}
+
+
+// WithUnboundedQueue makes the job queue unbounded. Submit...() methods never wait for room then.
+func WithUnboundedQueue() Option {
+	return func(cfg *poolConfig) error {
+		cfg.qcap = 0
+		cfg.unbounded = true
+		return nil
+	}
+}
+
+
+// WithCancelFunc sets the cancel function of the worker-pool context. By default, New() derives
+// the worker-pool context from its ctx argument along with a cancel function.
+func WithCancelFunc(cfunc context.CancelFunc) Option {
+	return func(cfg *poolConfig) error {
+		if cfunc == nil {
+			return fmt.Errorf("%w: nil cancel function", ErrInvalidOption)
+		}
+		cfg.cancelFunc = cfunc
+		return nil
+	}
+}
+
+
+// WithName sets the name of the worker-pool.
+func WithName(name string) Option {
+	return func(cfg *poolConfig) error {
+		cfg.name = name
+		return nil
+	}
+}
+
+
+// WithMessages sets the informative start and cancel messages of the worker-pool.
+func WithMessages(smsg, cmsg string) Option {
+	return func(cfg *poolConfig) error {
+		cfg.smsg = smsg
+		cfg.cmsg = cmsg
+		return nil
+	}
+}
+
+
+// WithMaxJobCnt sets WorkerPoolOptions.MaxJobCnt and WorkerPoolOptions.ShouldTerminate. Rather
+// use WithTermination(AfterCompleted(n)).
+func WithMaxJobCnt(n int, shouldTerminate bool) Option {
+	return func(cfg *poolConfig) error {
+		if n < 0 {
+			return fmt.Errorf("%w: max job count %d, must not be negative", ErrInvalidOption, n)
+		}
+		cfg.opts.MaxJobCnt = n
+		cfg.opts.ShouldTerminate = shouldTerminate
+		return nil
+	}
+}
+
+
+// WithResults publishes execution status of each job on the channel returned by Results().
+// size is the size of the channel, 0 means default.
+func WithResults(size int32) Option {
+	return func(cfg *poolConfig) error {
+		if (size < 0) || (size > maxChanSize) {
+			return fmt.Errorf("%w: results channel size %d, must be in between 0 and %d", ErrInvalidOption, size, maxChanSize)
+		}
+		cfg.opts.IsResponse = true
+		cfg.opts.ResultQSize = size
+		return nil
+	}
+}
+
+
+// WithResultWorkers sets the no. of workers of the result processing stage.
+func WithResultWorkers(n int32) Option {
+	return func(cfg *poolConfig) error {
+		if n < 1 {
+			return fmt.Errorf("%w: %d result workers, must be at least 1", ErrInvalidSize, n)
+		}
+		cfg.opts.ResultWorkers = n
+		return nil
+	}
+}
+
+
+// WithOnResultError sets WorkerPoolOptions.OnResultError.
+func WithOnResultError(f func(Job, error)) Option {
+	return func(cfg *poolConfig) error {
+		cfg.opts.OnResultError = f
+		return nil
+	}
+}
+
+
+// WithOnStateChange sets WorkerPoolOptions.OnStateChange.
+func WithOnStateChange(f func(from, to PoolState)) Option {
+	return func(cfg *poolConfig) error {
+		cfg.opts.OnStateChange = f
+		return nil
+	}
+}
+
+
+// WithOnPanic sets WorkerPoolOptions.OnPanic.
+func WithOnPanic(f func(*PanicError)) Option {
+	return func(cfg *poolConfig) error {
+		cfg.opts.OnPanic = f
+		return nil
+	}
+}
+
+
+// WithOnProgress sets WorkerPoolOptions.OnProgress.
+func WithOnProgress(f func(Progress)) Option {
+	return func(cfg *poolConfig) error {
+		cfg.opts.OnProgress = f
+		return nil
+	}
+}
+
+
+// WithJobTimeout sets the default timeout of each job.
+func WithJobTimeout(d time.Duration) Option {
+	return func(cfg *poolConfig) error {
+		if d < 0 {
+			return fmt.Errorf("%w: job timeout %s, must not be negative", ErrInvalidOption, d)
+		}
+		cfg.opts.JobTimeout = d
+		return nil
+	}
+}
+
+
+// WithRetryPolicy sets the default retry policy of each job.
+func WithRetryPolicy(rp *RetryPolicy) Option {
+	return func(cfg *poolConfig) error {
+		if err := validateRetryPolicy(rp); err != nil {
+			return err
+		}
+		cfg.opts.RetryPolicy = rp
+		return nil
+	}
+}
+
+
+// WithDeadLetterSink sets the dead-letter sink and, optionally, the decoder used to requeue the
+// dead letters that aren't in memory.
+func WithDeadLetterSink(sink DeadLetterSink, decoder DeadLetterDecoder) Option {
+	return func(cfg *poolConfig) error {
+		if sink == nil {
+			return fmt.Errorf("%w: nil dead-letter sink", ErrInvalidOption)
+		}
+		cfg.opts.DeadLetterSink = sink
+		cfg.opts.DeadLetterDecoder = decoder
+		return nil
+	}
+}
+
+
+// WithOnDeadLetterError sets WorkerPoolOptions.OnDeadLetterError.
+func WithOnDeadLetterError(f func(Job, error)) Option {
+	return func(cfg *poolConfig) error {
+		cfg.opts.OnDeadLetterError = f
+		return nil
+	}
+}
+
+
+// WithAutoscale enables the autoscaler.
+func WithAutoscale(as AutoscaleOptions) Option {
+	return func(cfg *poolConfig) error {
+		if err := as.validate(); err != nil {
+			return err
+		}
+		cfg.opts.Autoscale = &as
+		return nil
+	}
+}
+
+
+// WithOptions applies WorkerPoolOptions as a whole, validated the same way as the individual
+// options. It replaces whatever the options preceding it have set in WorkerPoolOptions, options
+// that follow it override its fields.
+func WithOptions(opts WorkerPoolOptions) Option {
+	return func(cfg *poolConfig) error {
+		if err := opts.validate(); err != nil {
+			return err
+		}
+		cfg.opts = opts
+		return nil
+	}
+}
+
+
+// nil if the retry policy is nil or has no negative value.
+func validateRetryPolicy(rp *RetryPolicy) error {
+	if (rp != nil) && ((rp.MaxAttempts < 0) || (rp.InitialBackoff < 0) || (rp.MaxBackoff < 0) || (rp.Jitter < 0)) {
+		return fmt.Errorf("%w: retry policy has a negative value", ErrInvalidOption)
+	}
+
+	return nil
+}
+
+
+// validates the options passed through WithOptions(). zero values mean defaults.
+func (opts *WorkerPoolOptions) validate() error {
+	switch {
+		case opts.MaxJobCnt < 0:
+			return fmt.Errorf("%w: max job count %d, must not be negative", ErrInvalidOption, opts.MaxJobCnt)
+		case (opts.ResultQSize < 0) || (opts.ResultQSize > maxChanSize):
+			return fmt.Errorf("%w: results channel size %d, must be in between 0 and %d", ErrInvalidOption, opts.ResultQSize, maxChanSize)
+		case opts.ResultWorkers < 0:
+			return fmt.Errorf("%w: %d result workers, must not be negative", ErrInvalidSize, opts.ResultWorkers)
+		case opts.JobTimeout < 0:
+			return fmt.Errorf("%w: job timeout %s, must not be negative", ErrInvalidOption, opts.JobTimeout)
+		case opts.JobHistoryMaxAge < 0:
+			return fmt.Errorf("%w: job history age %s, must not be negative", ErrInvalidOption, opts.JobHistoryMaxAge)
+	}
+
+	for _, tp := range opts.Terminate {
+		if tp == nil {
+			return fmt.Errorf("%w: nil termination policy", ErrInvalidOption)
+		}
+	}
+
+	if err := validateRetryPolicy(opts.RetryPolicy); err != nil {
+		return err
+	}
+
+	if opts.Autoscale != nil {
+		return opts.Autoscale.validate()
+	}
+
+	return nil
+}
+
+
+/* *****************************************************************************
+Description : Creates a new worker-pool instance with functional options.
+
+Receiver    : NA
+
+Implements  : NA
+
+Arguments   :
+1> ctx context.Context: Upstream context, the worker-pool context is derived from it unless
+WithCancelFunc() is passed. In the latter case, ctx is the worker-pool context itself.
+2> opts ...Option: Worker-pool options.
+
+Return value:
+1> *WorkerPool: Reference to the newly created worker-pool.
+2> error: Descriptive error, wrapping ErrInvalidSize or ErrInvalidOption, if an option is invalid.
+
+Additional note:
+Unlike NewWorkerPool(), sizes aren't clamped. Default is 10 workers and a job queue 100 times
+the no. of workers.
+***************************************************************************** */
+func New(ctx context.Context, opts ...Option) (*WorkerPool, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("%w: nil context", ErrInvalidOption)
+	}
+
+	cfg := poolConfig{}
+	for _, opt := range opts {
+		if err := opt(&cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	if as := cfg.opts.Autoscale; as != nil {
+		if err := as.validate(); err != nil {
+			return nil, err
+		}
+
+		if (cfg.wpsize != 0) && ((cfg.wpsize < as.MinWorkers) || (cfg.wpsize > as.MaxWorkers)) {
+			return nil, fmt.Errorf("%w: %d workers, must be in between autoscale minimum %d and maximum %d",
+				ErrInvalidSize, cfg.wpsize, as.MinWorkers, as.MaxWorkers)
+		}
+	}
+
+	if cfg.priority && (cfg.fair != nil) {
+		return nil, fmt.Errorf("%w: priority queue and fair queue are mutually exclusive", ErrInvalidOption)
+	}
+
+	if cfg.cancelFunc == nil {
+		ctx, cfg.cancelFunc = context.WithCancel(ctx)
+	}
+
+	return newWorkerPool(ctx, cfg)
+}
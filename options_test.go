@@ -0,0 +1,95 @@
+package gowp
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestNewInvalidOptions(t *testing.T) {
+	big := math.MaxInt32
+	big++  // wraps around on 32-bit platforms, invalid either way.
+
+	tests := []struct {
+		name string
+		opts []Option
+		want error
+	}{
+		{"no workers", []Option{WithWorkers(0)}, ErrInvalidSize},
+		{"no queue capacity", []Option{WithQueueCapacity(0)}, ErrInvalidOption},
+		{"queue capacity over int32", []Option{WithQueueCapacity(big), WithResults(0)}, ErrInvalidOption},
+		{"negative results size", []Option{WithResults(-1)}, ErrInvalidOption},
+		{"results size too large", []Option{WithResults(maxChanSize + 1)}, ErrInvalidOption},
+		{"no result workers", []Option{WithResultWorkers(0)}, ErrInvalidSize},
+		{"negative job timeout", []Option{WithJobTimeout(-time.Second)}, ErrInvalidOption},
+		{"negative retry policy", []Option{WithRetryPolicy(&RetryPolicy{MaxAttempts: -1})}, ErrInvalidOption},
+		{"negative max job count", []Option{WithMaxJobCnt(-1, true)}, ErrInvalidOption},
+		{"nil termination policy", []Option{WithTermination(nil)}, ErrInvalidOption},
+		{"nil cancel function", []Option{WithCancelFunc(nil)}, ErrInvalidOption},
+		{"autoscale minimum", []Option{WithAutoscale(AutoscaleOptions{MinWorkers: 0, MaxWorkers: 2})}, ErrInvalidSize},
+		{"workers outside autoscale", []Option{WithWorkers(5), WithAutoscale(AutoscaleOptions{MinWorkers: 1, MaxWorkers: 2})}, ErrInvalidSize},
+		{"priority and fair queue", []Option{WithPriorityQueue(0), WithFairQueue(FairQueueOptions{})}, ErrInvalidOption},
+		{"options negative results size", []Option{WithOptions(WorkerPoolOptions{IsResponse: true, ResultQSize: -1})}, ErrInvalidOption},
+		{"options results size too large", []Option{WithOptions(WorkerPoolOptions{IsResponse: true, ResultQSize: maxChanSize + 1})}, ErrInvalidOption},
+		{"options negative result workers", []Option{WithOptions(WorkerPoolOptions{ResultWorkers: -1})}, ErrInvalidSize},
+		{"options negative max job count", []Option{WithOptions(WorkerPoolOptions{MaxJobCnt: -1})}, ErrInvalidOption},
+		{"options negative job timeout", []Option{WithOptions(WorkerPoolOptions{JobTimeout: -1})}, ErrInvalidOption},
+		{"options negative history age", []Option{WithOptions(WorkerPoolOptions{JobHistoryMaxAge: -1})}, ErrInvalidOption},
+		{"options negative retry policy", []Option{WithOptions(WorkerPoolOptions{RetryPolicy: &RetryPolicy{Jitter: -1}})}, ErrInvalidOption},
+		{"options nil termination policy", []Option{WithOptions(WorkerPoolOptions{Terminate: []TerminationPolicy{nil}})}, ErrInvalidOption},
+		{"options autoscale", []Option{WithOptions(WorkerPoolOptions{Autoscale: &AutoscaleOptions{MinWorkers: 3, MaxWorkers: 2}})}, ErrInvalidSize},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pwp, err := New(context.Background(), tt.opts...)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("New(): %v, want %v", err, tt.want)
+			}
+			if pwp != nil {
+				t.Fatal("New() returned a worker-pool along with an error")
+			}
+		})
+	}
+}
+
+
+func TestNewSizes(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+		workers int32
+		qcap int
+		resultq int
+	}{
+		{"defaults", []Option{WithResults(0)}, minWPSize, int(minWPSize * jpwpfactor), int(minWPSize * jpwpfactor)},
+		{"workers", []Option{WithWorkers(3), WithResults(0)}, 3, 300, 300},
+		{"queue capacity", []Option{WithWorkers(2), WithQueueCapacity(7), WithResults(0)}, 2, 7, 7},
+		{"results size", []Option{WithWorkers(2), WithQueueCapacity(7), WithResults(4)}, 2, 7, 4},
+		{"unbounded queue", []Option{WithWorkers(2), WithUnboundedQueue(), WithResults(0)}, 2, 0, 200},
+		{"autoscale", []Option{WithAutoscale(AutoscaleOptions{MinWorkers: 1, MaxWorkers: 4}), WithResults(0)}, 1, 400, 400},
+		{"options then option", []Option{WithOptions(WorkerPoolOptions{IsResponse: true, ResultQSize: 9}), WithWorkers(1)}, 1, 100, 9},
+		{"largest queue capacity", []Option{WithWorkers(1), WithQueueCapacity(math.MaxInt32), WithResults(0)}, 1, math.MaxInt32, int(maxChanSize)},
+		{"many workers", []Option{WithWorkers(4096), WithResults(0)}, 4096, 409600, int(maxChanSize)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tp := newPool(t, tt.opts...)
+			if n := tp.GetSize(); n != tt.workers {
+				t.Errorf("workers %d, want %d", n, tt.workers)
+			}
+			if tp.qcap != tt.qcap {
+				t.Errorf("queue capacity %d, want %d", tp.qcap, tt.qcap)
+			}
+			if n := cap(tp.resultq); n != tt.resultq {
+				t.Errorf("results channel size %d, want %d", n, tt.resultq)
+			}
+			if n := cap(tp.rjobq); n > int(maxChanSize) {
+				t.Errorf("result processing queue size %d, want at most %d", n, maxChanSize)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package gowp
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestPanic(t *testing.T) {
+	tests := []struct {
+		name string
+		value interface{}
+		inResult bool  // panics in ProcessResult() rather than in Process().
+		wantIs error
+	}{
+		{"string", "boom", false, nil},
+		{"error", errTest, false, errTest},
+		{"in result processing", "boom", true, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var reported []*PanicError
+			tp := startPool(t, WithWorkers(1), WithOnPanic(func(pe *PanicError) {
+				mu.Lock()
+				reported = append(reported, pe)
+				mu.Unlock()
+			}))
+
+			value, inResult := tt.value, tt.inResult
+			h := mustSubmit(t, tp, Func("panicky", func(context.Context) (interface{}, error) {
+				if inResult {
+					return panickyResult{value}, nil
+				}
+				panic(value)
+			}))
+
+			_, err := wait(t, h)
+			var pe *PanicError
+			if !errors.As(err, &pe) {
+				t.Fatalf("job: %v, want *PanicError", err)
+			}
+			if (pe.Value != tt.value) || (pe.JobID != h.GetID()) || (pe.JobName != "panicky") {
+				t.Fatalf("PanicError %+v", pe)
+			}
+			if !strings.Contains(string(pe.Stack), "panic") {
+				t.Fatalf("stack trace:\n%s", pe.Stack)
+			}
+			if (tt.wantIs != nil) && !errors.Is(err, tt.wantIs) {
+				t.Fatalf("job: %v, want it to wrap %v", err, tt.wantIs)
+			}
+
+			mu.Lock()
+			if (len(reported) != 1) || (reported[0] != pe) {
+				t.Fatalf("OnPanic() invoked with %v", reported)
+			}
+			mu.Unlock()
+
+			// the worker-pool carries on.
+			if v, err := wait(t, mustSubmit(t, tp, valueJob(1))); (err != nil) || (v != 1) {
+				t.Fatalf("next job: %v, %v", v, err)
+			}
+			if !tt.inResult {
+				if s := tp.Stats(); (s.Panicked != 1) || (s.Failed != 1) {
+					t.Fatalf("Stats() %d panicked, %d failed, want 1 each", s.Panicked, s.Failed)
+				}
+				if ji, _ := tp.JobInfo(h.GetID()); ji.State != JobFailed {
+					t.Fatalf("job %s, want failed", ji.State)
+				}
+			}
+		})
+	}
+}
+
+
+// result that panics once it's processed.
+type panickyResult struct {
+	value interface{}
+}
+
+func (r panickyResult) ProcessResult(context.Context) (interface{}, error) {
+	panic(r.value)
+}
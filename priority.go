@@ -0,0 +1,245 @@
+/* *****************************************************************************
+Copyright (c) 2023, sameeroak1110 (sameeroak1110@gmail.com)
+BSD 3-Clause License.
+
+Package     : github.com/sameeroak1110/gowp
+Filename    : github.com/sameeroak1110/gowp/priority.go
+File-type   : GoLang source code file
+
+Compiler/Runtime: go version go1.20.5 linux/amd64
+
+Version History
+Version     : 1.0
+Author      : Sameer Oak (sameeroak1110@gmail.com)
+
+Description :
+- Priority job queue, enabled through WithPriorityQueue().
+- Jobs are kept in a binary heap. Higher the priority, sooner the job is popped. Jobs of the
+same priority are popped in FIFO order.
+- With aging, a job gains one priority level for each aging interval it waits in the queue.
+Thus, low priority jobs aren't starved by a steady flow of high priority ones. The aged
+priority grows alike for all the queued jobs, therefore, it's applied as a static heap key:
+submission time minus priority times aging interval, smaller the key sooner the job is popped.
+***************************************************************************** */
+package gowp
+
+import (
+	"container/heap"
+	"fmt"
+	"time"
+)
+
+// - Prioritizer is optional. A JobProcessor implements it to have a priority other than the
+// default 0. WithPriority() submit option precedes it.
+// - Priority matters only if the worker-pool has a priority queue, see WithPriorityQueue().
+type Prioritizer interface {
+	Priority() int
+}
+
+// statistics of a priority level.
+type PriorityStats struct {
+	Submitted  uint64        // no. of jobs of this priority pushed to the queue.
+	Dispatched uint64        // no. of jobs of this priority popped from the queue.
+	Queued     int           // no. of jobs of this priority in the queue right now.
+	AvgWait    time.Duration // average queue wait of the popped jobs.
+	MaxWait    time.Duration // maximum queue wait of the popped jobs.
+}
+
+// job heap, implements heap.Interface.
+type jobHeap struct {
+	jobs []Job
+	aging time.Duration
+}
+
+// priority job queue.
+type priorityQueue struct {
+	h jobHeap
+	stats map[int]*PriorityStats
+	waits map[int]time.Duration  // total queue wait of the popped jobs, by priority.
+}
+
+
+func (h *jobHeap) Len() int {
+	return len(h.jobs)
+}
+
+
+func (h *jobHeap) Less(i, k int) bool {
+	a, b := h.jobs[i], h.jobs[k]
+
+	if h.aging > 0 {
+		ka := a.submittedAt.UnixNano() - int64(a.priority) * int64(h.aging)
+		kb := b.submittedAt.UnixNano() - int64(b.priority) * int64(h.aging)
+		if ka != kb {
+			return ka < kb
+		}
+	} else if a.priority != b.priority {
+		return a.priority > b.priority
+	}
+
+	return a.id < b.id
+}
+
+
+func (h *jobHeap) Swap(i, k int) {
+	h.jobs[i], h.jobs[k] = h.jobs[k], h.jobs[i]
+}
+
+
+func (h *jobHeap) Push(x interface{}) {
+	h.jobs = append(h.jobs, x.(Job))
+}
+
+
+func (h *jobHeap) Pop() interface{} {
+	n := len(h.jobs) - 1
+	j := h.jobs[n]
+	h.jobs[n] = Job{}  // lets go of the reference.
+	h.jobs = h.jobs[:n]
+
+	return j
+}
+
+
+// creates a priority queue. aging of 0 means strict priority order.
+func newPriorityQueue(aging time.Duration) *priorityQueue {
+	return &priorityQueue {
+		h: jobHeap{aging: aging},
+		stats: make(map[int]*PriorityStats),
+		waits: make(map[int]time.Duration),
+	}
+}
+
+
+func (q *priorityQueue) statsOf(priority int) *PriorityStats {
+	ps, ok := q.stats[priority]
+	if !ok {
+		ps = &PriorityStats{}
+		q.stats[priority] = ps
+	}
+
+	return ps
+}
+
+
+func (q *priorityQueue) push(j Job) {
+	heap.Push(&q.h, j)
+
+	ps := q.statsOf(j.priority)
+	ps.Submitted++
+	ps.Queued++
+}
+
+
+func (q *priorityQueue) pop() (Job, bool) {
+	if q.h.Len() == 0 {
+		return Job{}, false
+	}
+
+	j := heap.Pop(&q.h).(Job)
+
+	wait := time.Since(j.submittedAt)
+	ps := q.statsOf(j.priority)
+	ps.Dispatched++
+	ps.Queued--
+	q.waits[j.priority] += wait
+	ps.AvgWait = q.waits[j.priority] / time.Duration(ps.Dispatched)
+	if wait > ps.MaxWait {
+		ps.MaxWait = wait
+	}
+
+	return j, true
+}
+
+
+func (q *priorityQueue) peek() (Job, bool) {
+	if q.h.Len() == 0 {
+		return Job{}, false
+	}
+
+	return q.h.jobs[0], true
+}
+
+
+func (q *priorityQueue) len() int {
+	return q.h.Len()
+}
+
+
+// removes the jobs for which pred is true. they aren't counted as dispatched.
+func (q *priorityQueue) removeWhere(pred func(Job) bool) []Job {
+	var removed []Job
+	n := 0
+	for _, j := range q.h.jobs {
+		if pred(j) {
+			removed = append(removed, j)
+			q.statsOf(j.priority).Queued--
+			continue
+		}
+		q.h.jobs[n] = j
+		n++
+	}
+
+	if len(removed) == 0 {
+		return nil
+	}
+
+	for i := n; i < len(q.h.jobs); i++ {
+		q.h.jobs[i] = Job{}  // lets go of the reference.
+	}
+	q.h.jobs = q.h.jobs[:n]
+	heap.Init(&q.h)
+
+	return removed
+}
+
+
+// WithPriorityQueue makes the job queue a priority queue. A queued job gains one priority level
+// for each aging interval it waits, 0 means strict priority order.
+func WithPriorityQueue(aging time.Duration) Option {
+	return func(cfg *poolConfig) error {
+		if aging < 0 {
+			return fmt.Errorf("%w: priority aging %s, must not be negative", ErrInvalidOption, aging)
+		}
+		cfg.priority = true
+		cfg.aging = aging
+		return nil
+	}
+}
+
+
+// WithPriority sets the priority of the job, it precedes the job's own through Prioritizer.
+func WithPriority(priority int) SubmitOption {
+	return func(sc *submitConfig) {
+		sc.priority = &priority
+	}
+}
+
+
+/* *****************************************************************************
+Description : Returns statistics of each priority level.
+
+Receiver    : *WorkerPool
+
+Implements  : NA
+
+Arguments   : NA
+
+Return value:
+1> map[int]PriorityStats: Statistics by priority. Empty unless the worker-pool has a priority queue.
+
+Additional note: NA
+***************************************************************************** */
+func (pwp *WorkerPool) PriorityStats() map[int]PriorityStats {
+	pwp.qmu.Lock()
+	defer pwp.qmu.Unlock()
+
+	stats := make(map[int]PriorityStats)
+	if q, ok := pwp.jobq.(*priorityQueue); ok {
+		for priority, ps := range q.stats {
+			stats[priority] = *ps
+		}
+	}
+
+	return stats
+}
@@ -0,0 +1,121 @@
+package gowp
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPriorityQueueOrder(t *testing.T) {
+	base := time.Now()
+	type queued struct {
+		priority int
+		age time.Duration  // how long before base the job was submitted.
+	}
+
+	tests := []struct {
+		name string
+		aging time.Duration
+		jobs []queued
+		want []uint64  // IDs in pop order, the jobs are numbered from 1.
+	}{
+		{"strict", 0, []queued{{0, 0}, {5, 0}, {1, 0}, {5, 0}}, []uint64{2, 4, 3, 1}},
+		{"strict ignores age", 0, []queued{{0, time.Hour}, {1, 0}}, []uint64{2, 1}},
+		{"aged past a higher priority", time.Second, []queued{{0, 3 * time.Second}, {2, 0}}, []uint64{1, 2}},
+		{"not aged enough", time.Second, []queued{{0, time.Second}, {2, 0}}, []uint64{2, 1}},
+		{"equal aged priority is FIFO", time.Second, []queued{{0, 2 * time.Second}, {2, 0}}, []uint64{1, 2}},
+		{"same priority is FIFO", time.Second, []queued{{3, 0}, {3, 0}, {3, 0}}, []uint64{1, 2, 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := newPriorityQueue(tt.aging)
+			for i, qj := range tt.jobs {
+				q.push(Job{id: uint64(i + 1), priority: qj.priority, submittedAt: base.Add(-qj.age)})
+			}
+			if q.len() != len(tt.jobs) {
+				t.Fatalf("len() = %d, want %d", q.len(), len(tt.jobs))
+			}
+
+			var got []uint64
+			for {
+				j, ok := q.pop()
+				if !ok {
+					break
+				}
+				got = append(got, j.id)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("popped %v, want %v", got, tt.want)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Fatalf("popped %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+
+func TestPriorityQueueStats(t *testing.T) {
+	q := newPriorityQueue(0)
+	now := time.Now()
+	q.push(Job{id: 1, priority: 1, submittedAt: now})
+	q.push(Job{id: 2, priority: 1, submittedAt: now})
+	q.push(Job{id: 3, priority: 2, submittedAt: now})
+	q.pop()
+	q.pop()
+
+	if ps := q.stats[2]; (ps.Submitted != 1) || (ps.Dispatched != 1) || (ps.Queued != 0) {
+		t.Fatalf("stats of priority 2 %+v", *ps)
+	}
+	if ps := q.stats[1]; (ps.Submitted != 2) || (ps.Dispatched != 1) || (ps.Queued != 1) {
+		t.Fatalf("stats of priority 1 %+v", *ps)
+	}
+
+	removed := q.removeWhere(func(j Job) bool { return j.id == 2 })
+	if (len(removed) != 1) || (q.len() != 0) || (q.stats[1].Queued != 0) || (q.stats[1].Dispatched != 1) {
+		t.Fatalf("removeWhere() = %v, stats of priority 1 %+v", removed, *q.stats[1])
+	}
+}
+
+
+func TestPriorityPool(t *testing.T) {
+	tp := startPool(t, WithWorkers(1), WithPriorityQueue(0))
+
+	started, release := make(chan struct{}), make(chan struct{})
+	mustSubmit(t, tp, blockJob(started, release))
+	<-started
+
+	var mu sync.Mutex
+	var order []int
+	var hs []*JobHandle
+	for _, p := range []int{1, 3, 2, 3} {
+		p := p
+		job := Func("prioritized", func(context.Context) (interface{}, error) {
+			mu.Lock()
+			order = append(order, p)
+			mu.Unlock()
+			return nil, nil
+		})
+		hs = append(hs, mustSubmit(t, tp, job, WithPriority(p)))
+	}
+	close(release)
+	for _, h := range hs {
+		wait(t, h)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []int{3, 3, 2, 1}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("ran by priority %v, want %v", order, want)
+		}
+	}
+	if ps := tp.PriorityStats(); ps[3].Dispatched != 2 {
+		t.Fatalf("PriorityStats() %+v", ps)
+	}
+}
@@ -0,0 +1,330 @@
+/* *****************************************************************************
+Copyright (c) 2023, sameeroak1110 (sameeroak1110@gmail.com)
+BSD 3-Clause License.
+
+Package     : github.com/sameeroak1110/gowp
+Filename    : github.com/sameeroak1110/gowp/queue.go
+File-type   : GoLang source code file
+
+Compiler/Runtime: go version go1.20.5 linux/amd64
+
+Version History
+Version     : 1.0
+Author      : Sameer Oak (sameeroak1110@gmail.com)
+
+Description :
+- Job queue of the worker-pool.
+- Jobs are pushed by the upstream through Submit...() methods and popped by Start().
+- The queue is guarded by WorkerPool.qmu. Blocked producers and the waiting Start() are woken
+up through channels so that they can as well honour the context cancellation.
+***************************************************************************** */
+package gowp
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+
+// - Job queue of the worker-pool. Implementations decide the order in which jobs are popped.
+// - Not safe for concurrent use, guarded by WorkerPool.qmu.
+type jobQueue interface {
+	push(Job)
+	pop() (Job, bool)
+	peek() (Job, bool)
+	len() int
+	removeWhere(func(Job) bool) []Job
+}
+
+// job queue that limits the queued jobs by something other than its length, e.g., tenant.
+type limitedQueue interface {
+	full(Job) bool
+}
+
+// FIFO job queue, the default.
+type fifoQueue struct {
+	jobs []Job
+	head int
+}
+
+
+func (q *fifoQueue) push(j Job) {
+	q.jobs = append(q.jobs, j)
+}
+
+
+func (q *fifoQueue) pop() (Job, bool) {
+	if q.head >= len(q.jobs) {
+		return Job{}, false
+	}
+
+	j := q.jobs[q.head]
+	q.jobs[q.head] = Job{}  // lets go of the reference.
+	q.head++
+
+	// reclaims the consumed part once it dominates the backing array.
+	if q.head == len(q.jobs) {
+		q.jobs = q.jobs[:0]
+		q.head = 0
+	} else if (q.head >= 1024) && (q.head * 2 >= len(q.jobs)) {
+		n := copy(q.jobs, q.jobs[q.head:])
+		q.jobs = q.jobs[:n]
+		q.head = 0
+	}
+
+	return j, true
+}
+
+
+func (q *fifoQueue) peek() (Job, bool) {
+	if q.head >= len(q.jobs) {
+		return Job{}, false
+	}
+
+	return q.jobs[q.head], true
+}
+
+
+func (q *fifoQueue) len() int {
+	return len(q.jobs) - q.head
+}
+
+
+// removes the jobs for which pred is true, the rest keep their order.
+func (q *fifoQueue) removeWhere(pred func(Job) bool) []Job {
+	var removed []Job
+	n := q.head
+	for i := q.head; i < len(q.jobs); i++ {
+		if pred(q.jobs[i]) {
+			removed = append(removed, q.jobs[i])
+			continue
+		}
+		q.jobs[n] = q.jobs[i]
+		n++
+	}
+
+	for i := n; i < len(q.jobs); i++ {
+		q.jobs[i] = Job{}  // lets go of the reference.
+	}
+	q.jobs = q.jobs[:n]
+
+	if q.head == len(q.jobs) {
+		q.jobs = q.jobs[:0]
+		q.head = 0
+	}
+
+	return removed
+}
+
+
+// wakes up Start() if it's waiting for a job. must not block.
+func (pwp *WorkerPool) notifyJob() {
+	select {
+		case pwp.qnotify <- struct{}{}:
+		default:
+	}
+}
+
+
+// wakes up producers waiting for room in the job queue. qmu must be held.
+func (pwp *WorkerPool) notifySpace() {
+	if pwp.qwaiters == 0 {
+		return
+	}
+
+	close(pwp.qspace)
+	pwp.qspace = make(chan struct{})
+}
+
+
+/* *****************************************************************************
+Description : Pushes a job to the job queue.
+
+Receiver    : *WorkerPool
+
+Implements  : NA
+
+Arguments   :
+1> ctx context.Context: Bounds the wait for room in the job queue.
+2> j Job: Job to be pushed.
+3> block bool: If false, doesn't wait for room in the job queue.
+
+Return value:
+1> error: ErrPoolStopped if the worker-pool has been stopped. ErrQueueFull, or ErrTenantQueueFull,
+if block is false and the job queue is full. ctx.Err() if ctx is done before there's room in the
+job queue, wrapped along with ErrQueueFull, or ErrTenantQueueFull, as to which queue was full.
+
+Additional note: NA
+***************************************************************************** */
+func (pwp *WorkerPool) enqueue(ctx context.Context, j Job, block bool) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	for {
+		pwp.qmu.Lock()
+		if pwp.qclosed {
+			pwp.qmu.Unlock()
+			return ErrPoolStopped
+		}
+
+		qfull := (pwp.qcap != 0) && (pwp.jobq.len() >= pwp.qcap)
+		lfull := false
+		if lq, ok := pwp.jobq.(limitedQueue); ok && !qfull {
+			lfull = lq.full(j)
+		}
+
+		if !qfull && !lfull {
+			// a job cancelled meanwhile, e.g., a scheduled one that's just due, isn't pushed.
+			if pwp.register(j, JobQueued) {
+				pwp.jobq.push(j)
+			}
+			pwp.qmu.Unlock()
+			pwp.notifyJob()
+			return nil
+		}
+
+		full := ErrQueueFull
+		if lfull {
+			full = ErrTenantQueueFull
+		}
+		if !block {
+			pwp.qmu.Unlock()
+			return full
+		}
+
+		pwp.qwaiters++
+		space := pwp.qspace
+		pwp.qmu.Unlock()
+
+		var err error
+		select {
+			case <-space:
+			case <-ctx.Done():
+				err = fmt.Errorf("%w: %w", ctx.Err(), full)
+		}
+
+		pwp.qmu.Lock()
+		pwp.qwaiters--
+		pwp.qmu.Unlock()
+
+		if err != nil {
+			return err
+		}
+	}
+}
+
+
+/* *****************************************************************************
+Description : Pops a job from the job queue, waits for one if the job queue is empty.
+
+Receiver    : *WorkerPool
+
+Implements  : NA
+
+Arguments   :
+1> ctx context.Context: Bounds the wait for a job, along with the worker-pool context.
+2> quit <-chan struct{}: Closed when the worker-pool is stopped, ends the wait as well.
+
+Return value:
+1> Job: Popped job.
+2> bool: false if the wait ended without a job. Also false, without waiting, if the job queue is
+empty and closed, or the worker-pool is paused.
+
+Additional note: NA
+***************************************************************************** */
+func (pwp *WorkerPool) dequeue(ctx context.Context, quit <-chan struct{}) (Job, bool) {
+	for {
+		select {
+			case <-quit:
+				return Job{}, false
+			case <-ctx.Done():
+				return Job{}, false
+			case <-pwp.GetContext().Done():
+				return Job{}, false
+			default:
+		}
+
+		if pwp.State() == StatePaused {
+			return Job{}, false  // paused while waiting for a job, Start() waits to be resumed.
+		}
+
+		pwp.qmu.Lock()
+		j, ok := pwp.jobq.pop()
+		if ok {
+			pwp.notifySpace()
+		}
+		closed := pwp.qclosed
+		pwp.qmu.Unlock()
+
+		if ok {
+			pwp.stats.observeQueueWait(time.Since(j.submittedAt))
+			return j, true
+		}
+
+		if closed {
+			return Job{}, false  // no more jobs are going to be pushed.
+		}
+
+		select {
+			case <-pwp.qnotify:
+			case <-ctx.Done():
+				return Job{}, false
+			case <-pwp.GetContext().Done():
+				return Job{}, false
+			case <-quit:
+				return Job{}, false
+		}
+	}
+}
+
+
+// closes the job queue for the producers. producers waiting for room get ErrPoolStopped.
+// Start() waiting for a job is woken up as there may be no more jobs.
+func (pwp *WorkerPool) closeQueue() {
+	pwp.qmu.Lock()
+	pwp.qclosed = true
+	close(pwp.qspace)
+	pwp.qspace = make(chan struct{})
+	pwp.qmu.Unlock()
+
+	pwp.notifyJob()
+}
+
+
+// opens the job queue for the producers again once the worker-pool is restarted.
+func (pwp *WorkerPool) openQueue() {
+	pwp.qmu.Lock()
+	defer pwp.qmu.Unlock()
+
+	pwp.qclosed = false
+}
+
+
+// true if the job queue is closed and all the queued jobs have been popped.
+func (pwp *WorkerPool) drained() bool {
+	pwp.qmu.Lock()
+	defer pwp.qmu.Unlock()
+
+	return pwp.qclosed && (pwp.jobq.len() == 0)
+}
+
+
+// pops all the queued jobs without executing them.
+func (pwp *WorkerPool) takeQueued() []Job {
+	pwp.qmu.Lock()
+	defer pwp.qmu.Unlock()
+
+	var jobs []Job
+	for {
+		j, ok := pwp.jobq.pop()
+		if !ok {
+			break
+		}
+		jobs = append(jobs, j)
+	}
+	pwp.notifySpace()
+
+	return jobs
+}
@@ -0,0 +1,409 @@
+/* *****************************************************************************
+Copyright (c) 2023, sameeroak1110 (sameeroak1110@gmail.com)
+BSD 3-Clause License.
+
+Package     : github.com/sameeroak1110/gowp
+Filename    : github.com/sameeroak1110/gowp/registry.go
+File-type   : GoLang source code file
+
+Compiler/Runtime: go version go1.20.5 linux/amd64
+
+Version History
+Version     : 1.0
+Author      : Sameer Oak (sameeroak1110@gmail.com)
+
+Description :
+- Job registry. Tracks the life-cycle of each job the worker-pool accepts: scheduled, queued,
+running, and finally succeeded, failed, cancelled, or timed out. Queried through JobInfo() and
+Jobs().
+- Each job has a record, shared by all the copies of the job, updated as the job moves along.
+Jobs that haven't finished are indexed by ID. Finished jobs are kept in a ring buffer of a fixed
+size, thus the memory stays bounded however many jobs the worker-pool runs.
+***************************************************************************** */
+package gowp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// default no. of finished jobs kept, see WorkerPoolOptions.JobHistory.
+const defaultJobHistory int = 1024
+
+// JobState is a life-cycle state of a job.
+type JobState int32
+
+const (
+	JobQueued JobState = iota  // waiting in the job queue, or behind the running job of its key.
+	JobScheduled               // waiting for its due time.
+	JobRunning                 // running, or waiting for a retry.
+	JobSucceeded               // finished without an error.
+	JobFailed                  // finished with an error, after its retries.
+	JobCancelled               // cancelled, or not run because the worker-pool has been stopped.
+	JobTimedOut                // its context expired before it finished.
+)
+
+// job scoped record shared by all the copies of a Job.
+type jobRecord struct {
+	mu sync.Mutex
+	state JobState
+	submittedAt time.Time
+	startedAt time.Time
+	finishedAt time.Time
+	wid int32                   // worker the job has been run on.
+	attempts int
+	err error                   // error the job finished with.
+	cancelled bool              // true once the job is cancelled through Cancel() or CancelWhere().
+	cancel context.CancelFunc   // cancels the context of the running job. nil unless the job is running.
+	ran bool                    // true once the job has been run.
+	done bool                   // true once the job handle is resolved.
+}
+
+// JobInfo describes a job and where it is in its life-cycle, see JobInfo() and Jobs().
+type JobInfo struct {
+	ID          uint64
+	Name        string
+	Tenant      string
+	Key         string
+	Metadata    map[string]string  // read only.
+	State       JobState
+	SubmittedAt time.Time
+	StartedAt   time.Time  // zero unless the job has been run.
+	FinishedAt  time.Time  // zero unless the job has finished.
+	WorkerID    int32      // worker the job has been run on, 0 unless it has been run.
+	Attempts    int        // no. of times the job has been run, including retries.
+	Err         error      // error the job has finished with, if any.
+}
+
+// finished jobs, a ring buffer.
+type jobHistory struct {
+	mu sync.Mutex
+	ring []JobInfo
+	next int                 // slot the next finished job goes to.
+	byID map[uint64]int      // slot of each job in the ring.
+	maxAge time.Duration     // 0 means no limit.
+}
+
+
+func (s JobState) String() string {
+	switch s {
+		case JobQueued:
+			return "queued"
+		case JobScheduled:
+			return "scheduled"
+		case JobRunning:
+			return "running"
+		case JobSucceeded:
+			return "succeeded"
+		case JobFailed:
+			return "failed"
+		case JobCancelled:
+			return "cancelled"
+		case JobTimedOut:
+			return "timed out"
+	}
+
+	return "unknown"
+}
+
+
+// IsFinal is true if the job has finished, one way or the other.
+func (s JobState) IsFinal() bool {
+	return s >= JobSucceeded
+}
+
+
+// WithJobHistory sets the no. of finished jobs kept for JobInfo() and Jobs(), and how long they're
+// reported for. Default is 1024 jobs and no age limit. Size 0 keeps none.
+func WithJobHistory(size int, maxAge time.Duration) Option {
+	return func(cfg *poolConfig) error {
+		if size < 0 {
+			return fmt.Errorf("%w: job history size %d, must not be negative", ErrInvalidOption, size)
+		}
+		if maxAge < 0 {
+			return fmt.Errorf("%w: job history age %s, must not be negative", ErrInvalidOption, maxAge)
+		}
+
+		cfg.opts.JobHistory = size
+		if size == 0 {
+			cfg.opts.JobHistory = -1
+		}
+		cfg.opts.JobHistoryMaxAge = maxAge
+		return nil
+	}
+}
+
+
+// ByState returns a filter of Jobs() that's true for the jobs in any of the states.
+func ByState(states ...JobState) func(JobInfo) bool {
+	return func(ji JobInfo) bool {
+		for _, s := range states {
+			if ji.State == s {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+
+// creates the history of finished jobs. nil if size isn't positive.
+func newJobHistory(size int, maxAge time.Duration) *jobHistory {
+	if size <= 0 {
+		return nil
+	}
+
+	return &jobHistory {
+		ring: make([]JobInfo, size),
+		byID: make(map[uint64]int, size),
+		maxAge: maxAge,
+	}
+}
+
+
+// records a finished job. the oldest one is evicted once the ring is full.
+func (h *jobHistory) add(ji JobInfo) {
+	if h == nil {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if old := h.ring[h.next]; old.ID != 0 {
+		delete(h.byID, old.ID)
+	}
+	h.ring[h.next] = ji
+	h.byID[ji.ID] = h.next
+	h.next = (h.next + 1) % len(h.ring)
+
+	return
+}
+
+
+// true if the finished job is still reported.
+func (h *jobHistory) fresh(ji JobInfo, now time.Time) bool {
+	return (h.maxAge == 0) || (now.Sub(ji.FinishedAt) <= h.maxAge)
+}
+
+
+func (h *jobHistory) get(id uint64) (JobInfo, bool) {
+	if h == nil {
+		return JobInfo{}, false
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	slot, ok := h.byID[id]
+	if !ok || !h.fresh(h.ring[slot], time.Now()) {
+		return JobInfo{}, false
+	}
+
+	return h.ring[slot], true
+}
+
+
+func (h *jobHistory) list() []JobInfo {
+	if h == nil {
+		return nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := time.Now()
+	jobs := make([]JobInfo, 0, len(h.byID))
+	for _, ji := range h.ring {
+		if (ji.ID != 0) && h.fresh(ji, now) {
+			jobs = append(jobs, ji)
+		}
+	}
+
+	return jobs
+}
+
+
+// describes the job. rec.mu must be held.
+func infoOf(j Job) JobInfo {
+	return JobInfo {
+		ID: j.id,
+		Name: j.name,
+		Tenant: j.tenant,
+		Key: j.key,
+		Metadata: j.metadata,
+		State: j.rec.state,
+		SubmittedAt: j.rec.submittedAt,
+		StartedAt: j.rec.startedAt,
+		FinishedAt: j.rec.finishedAt,
+		WorkerID: j.rec.wid,
+		Attempts: j.rec.attempts,
+		Err: j.rec.err,
+	}
+}
+
+
+// final state of a job as per its execution status.
+func finalState(js JobStatus) (JobState, error) {
+	err := js.err
+	if err == nil {
+		err = js.rerr
+	}
+
+	switch {
+		case err == nil:
+			return JobSucceeded, nil
+		case errors.Is(err, ErrJobCancelled), errors.Is(err, ErrPoolStopped), errors.Is(err, context.Canceled):
+			return JobCancelled, err
+		case errors.Is(err, context.DeadlineExceeded):
+			return JobTimedOut, err
+	}
+
+	return JobFailed, err
+}
+
+
+// indexes the job by ID in the given state. false if the job handle is resolved already, e.g.,
+// the job has been cancelled meanwhile.
+func (pwp *WorkerPool) register(j Job, state JobState) bool {
+	j.rec.mu.Lock()
+	defer j.rec.mu.Unlock()
+
+	if j.rec.done {
+		return false
+	}
+	j.rec.state = state
+
+	pwp.lmu.Lock()
+	_, known := pwp.live[j.id]
+	pwp.live[j.id] = j
+	pwp.lmu.Unlock()
+
+	if !known {
+		pwp.stats.submitted()
+	}
+
+	return true
+}
+
+
+// resolves the job handle, and moves the job from the index to the history.
+func (pwp *WorkerPool) resolve(j Job, js JobStatus) {
+	j.rec.mu.Lock()
+	if j.rec.done {
+		j.rec.mu.Unlock()
+		return
+	}
+	j.rec.done = true
+	j.rec.state, j.rec.err = finalState(js)
+	j.rec.finishedAt = time.Now()
+	ji := infoOf(j)
+	j.rec.mu.Unlock()
+
+	pwp.stats.finished(ji.State, ji.Err)
+	pwp.notifyTerminator()
+
+	// the job is in the history before it leaves the index, so that it's always found.
+	pwp.history.add(ji)
+	pwp.lmu.Lock()
+	delete(pwp.live, j.id)
+	pwp.lmu.Unlock()
+
+	j.handle.complete(js)
+
+	return
+}
+
+
+/* *****************************************************************************
+Description : Returns the life-cycle of a job.
+
+Receiver    : *WorkerPool
+
+Implements  : NA
+
+Arguments   :
+1> id uint64: ID of the job, see JobHandle.GetID().
+
+Return value:
+1> JobInfo: Job and its life-cycle state.
+2> bool: false if there's no such job, or it has finished and isn't in the history anymore.
+
+Additional note:
+Finished jobs are kept as per WorkerPoolOptions.JobHistory and JobHistoryMaxAge.
+***************************************************************************** */
+func (pwp *WorkerPool) JobInfo(id uint64) (JobInfo, bool) {
+	pwp.lmu.Lock()
+	j, ok := pwp.live[id]
+	pwp.lmu.Unlock()
+
+	if ok {
+		j.rec.mu.Lock()
+		ji := infoOf(j)
+		j.rec.mu.Unlock()
+		return ji, true
+	}
+
+	return pwp.history.get(id)
+}
+
+
+/* *****************************************************************************
+Description : Returns the jobs that match a filter.
+
+Receiver    : *WorkerPool
+
+Implements  : NA
+
+Arguments   :
+1> filter func(JobInfo) bool: true for the jobs to be returned, e.g., ByState(). nil returns all.
+
+Return value:
+1> []JobInfo: Unfinished jobs and the finished ones in the history, by ID.
+
+Additional note: NA
+***************************************************************************** */
+func (pwp *WorkerPool) Jobs(filter func(JobInfo) bool) []JobInfo {
+	finished := pwp.history.list()
+	seen := make(map[uint64]bool, len(finished))
+	for _, ji := range finished {
+		seen[ji.ID] = true
+	}
+
+	pwp.lmu.Lock()
+	live := make([]Job, 0, len(pwp.live))
+	for _, j := range pwp.live {
+		live = append(live, j)
+	}
+	pwp.lmu.Unlock()
+
+	jobs := make([]JobInfo, 0, len(finished) + len(live))
+	for _, ji := range finished {
+		if (filter == nil) || filter(ji) {
+			jobs = append(jobs, ji)
+		}
+	}
+	for _, j := range live {
+		if seen[j.id] {
+			continue  // has finished meanwhile.
+		}
+
+		j.rec.mu.Lock()
+		ji := infoOf(j)
+		j.rec.mu.Unlock()
+		if (filter == nil) || filter(ji) {
+			jobs = append(jobs, ji)
+		}
+	}
+
+	sort.Slice(jobs, func(i, k int) bool {
+		return jobs[i].ID < jobs[k].ID
+	})
+
+	return jobs
+}
@@ -0,0 +1,143 @@
+package gowp
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestFinalState(t *testing.T) {
+	tests := []struct {
+		name string
+		js JobStatus
+		want JobState
+	}{
+		{"succeeded", JobStatus{}, JobSucceeded},
+		{"failed", JobStatus{err: errTest}, JobFailed},
+		{"result failed", JobStatus{rerr: errTest}, JobFailed},
+		{"panicked", JobStatus{err: &PanicError{Value: "boom"}}, JobFailed},
+		{"cancelled", JobStatus{err: ErrJobCancelled}, JobCancelled},
+		{"cancelled context", JobStatus{err: context.Canceled}, JobCancelled},
+		{"pool stopped", JobStatus{err: ErrPoolStopped}, JobCancelled},
+		{"timed out", JobStatus{err: context.DeadlineExceeded}, JobTimedOut},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if s, _ := finalState(tt.js); s != tt.want {
+				t.Fatalf("finalState() = %s, want %s", s, tt.want)
+			}
+		})
+	}
+}
+
+
+func TestJobLifeCycle(t *testing.T) {
+	tp := startPool(t, WithWorkers(1))
+
+	started, release := make(chan struct{}), make(chan struct{})
+	running, err := tp.SubmitWithHandle(context.Background(), blockJob(started, release), WithTenant("t"), WithKey("k"))
+	if err != nil {
+		t.Fatalf("SubmitWithHandle(): %v", err)
+	}
+	<-started
+	queued, _ := tp.SubmitWithHandle(context.Background(), valueJob(1))
+	scheduled, _ := tp.SubmitAfter(time.Hour, valueJob(2))
+
+	tests := []struct {
+		h *JobHandle
+		want JobState
+	}{
+		{running, JobRunning},
+		{queued, JobQueued},
+		{scheduled, JobScheduled},
+	}
+	for _, tt := range tests {
+		ji, ok := tp.JobInfo(tt.h.GetID())
+		if !ok || (ji.State != tt.want) {
+			t.Fatalf("job %d %s, want %s", tt.h.GetID(), ji.State, tt.want)
+		}
+	}
+
+	close(release)
+	wait(t, running)
+	wait(t, queued)
+	ji, ok := tp.JobInfo(running.GetID())
+	if !ok || (ji.State != JobSucceeded) || (ji.Tenant != "t") || (ji.Key != "k") || (ji.Attempts != 1) ||
+		(ji.WorkerID == 0) || ji.StartedAt.IsZero() || ji.FinishedAt.Before(ji.StartedAt) {
+		t.Fatalf("finished job %+v", ji)
+	}
+
+	if n := len(tp.Jobs(ByState(JobSucceeded))); n != 2 {
+		t.Fatalf("%d jobs succeeded, want 2", n)
+	}
+	if n := len(tp.Jobs(nil)); n != 3 {
+		t.Fatalf("%d jobs, want 3", n)
+	}
+	if _, ok := tp.JobInfo(0); ok {
+		t.Fatal("JobInfo() of an unknown job")
+	}
+}
+
+
+func TestJobHistory(t *testing.T) {
+	tests := []struct {
+		name string
+		size int
+		maxAge time.Duration
+		jobs int
+		kept int
+	}{
+		{"bounded", 3, 0, 5, 3},
+		{"no history", 0, 0, 3, 0},
+		{"expired", 10, time.Millisecond, 3, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tp := startPool(t, WithWorkers(1), WithJobHistory(tt.size, tt.maxAge))
+
+			var ids []uint64
+			for i := 0; i < tt.jobs; i++ {
+				h, err := tp.SubmitWithHandle(context.Background(), valueJob(i))
+				if err != nil {
+					t.Fatalf("SubmitWithHandle(): %v", err)
+				}
+				wait(t, h)
+				ids = append(ids, h.GetID())
+			}
+			if tt.maxAge > 0 {
+				time.Sleep(2 * tt.maxAge)
+			}
+
+			if n := len(tp.Jobs(nil)); n != tt.kept {
+				t.Fatalf("%d jobs kept, want %d", n, tt.kept)
+			}
+			for i, id := range ids {
+				_, ok := tp.JobInfo(id)
+				if want := i >= len(ids) - tt.kept; ok != want {
+					t.Fatalf("JobInfo() of job %d: %t, want %t", i, ok, want)
+				}
+			}
+		})
+	}
+}
+
+
+func TestNewJob(t *testing.T) {
+	tp := newPool(t, WithWorkers(1))
+
+	j := tp.NewJob("named", valueJob(1))
+	if (j.GetID() == 0) || (j.GetName() != "named") || (j.GetData() == nil) {
+		t.Fatalf("NewJob() = %+v", j)
+	}
+	if (j.handle == nil) || (j.rec == nil) {
+		t.Fatal("NewJob() built a job without its handle or record")
+	}
+	if (j.handle.GetID() != j.GetID()) || (j.handle.GetName() != "named") {
+		t.Fatalf("handle of job %d %q, want the job's", j.handle.GetID(), j.handle.GetName())
+	}
+	if k := tp.NewJob("other", valueJob(2)); k.GetID() == j.GetID() {
+		t.Fatal("NewJob() reused an ID")
+	}
+}
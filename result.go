@@ -0,0 +1,130 @@
+/* *****************************************************************************
+Copyright (c) 2023, sameeroak1110 (sameeroak1110@gmail.com)
+BSD 3-Clause License.
+
+Package     : github.com/sameeroak1110/gowp
+Filename    : github.com/sameeroak1110/gowp/result.go
+File-type   : GoLang source code file
+
+Compiler/Runtime: go version go1.20.5 linux/amd64
+
+Version History
+Version     : 1.0
+Author      : Sameer Oak (sameeroak1110@gmail.com)
+
+Description :
+- Result processing stage. Results that implement JobResultProcessor are processed here, on
+a set of workers separate from the ones that execute jobs.
+***************************************************************************** */
+package gowp
+
+
+/* *****************************************************************************
+Description : Hands off the job result to the result processing stage.
+
+Receiver    : *WorkerPool
+
+Implements  : NA
+
+Arguments   :
+1> job Job: Job whose Process() method has returned.
+2> js JobStatus: Job execution status.
+
+Return value:
+1> bool: true if the result has been handed off. The result processing stage delivers the
+execution status in that case.
+
+Additional note:
+Only results that implement JobResultProcessor and are returned without an error are handed off.
+Blocks while the result queue is full, unless the worker-pool context is cancelled.
+***************************************************************************** */
+func (pwp *WorkerPool) handoffResult(job Job, js JobStatus) bool {
+	if js.err != nil {
+		return false
+	}
+
+	rp, ok := js.data.(JobResultProcessor)
+	if !ok {
+		return false
+	}
+
+	select {
+		case pwp.rjobq <- resultJob{job: job, js: js, rp: rp}:
+			return true
+
+		case <-pwp.GetContext().Done():
+			return false
+	}
+}
+
+
+/* *****************************************************************************
+Description : Runs the result processing stage. Invoked as a go-routine by Start().
+
+Receiver    : *WorkerPool
+
+Implements  : NA
+
+Arguments   :
+1> stop <-chan struct{}: Closed by Start() once no more results can be handed off.
+2> done chan<- struct{}: Closed once the result processing stage has stopped.
+
+Return value: NA
+
+Additional note:
+Results still queued when the stage stops are processed before it returns. However, if the
+worker-pool context is cancelled, they're delivered without being processed and their result
+error is the worker-pool context error.
+***************************************************************************** */
+func (pwp *WorkerPool) runResultStage(stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+
+	for {
+		select {
+			case <-stop:
+				for {
+					select {
+						case rj := <-pwp.rjobq:
+							if err := pwp.GetContext().Err(); err != nil {
+								rj.js.rerr = err
+								pwp.deliver(rj.job, rj.js)
+								break
+							}
+
+							wid := <-pwp.rworkers
+							pwp.rwg.Add(1)
+							go pwp.rexec(rj, wid)
+
+						default:
+							pwp.rwg.Wait()  // waits for each rexec() method finish its respective result.
+							return
+					}
+				}
+
+			case rj := <-pwp.rjobq:
+				wid := <-pwp.rworkers
+				pwp.rwg.Add(1)
+				go pwp.rexec(rj, wid)
+		}
+	}
+}
+
+
+func (pwp *WorkerPool) rexec(rj resultJob, wid int32) {
+	defer func() {
+		pwp.rworkers <- wid  // one more result worker is made available.
+		pwp.rwg.Done()
+	}()
+
+	rj.js.rdata, rj.js.rerr = pwp.invoke(rj.job, func() (interface{}, error) {
+		return rj.rp.ProcessResult(pwp.GetContext())
+	})
+	if (rj.js.rerr != nil) && (pwp.onResultError != nil) {
+		pwp.onResultError(rj.job, rj.js.rerr)
+	}
+
+	pwp.deliver(rj.job, rj.js)
+
+	return
+}
+
@@ -0,0 +1,156 @@
+package gowp
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestResults(t *testing.T) {
+	tests := []struct {
+		name string
+		job JobProcessor
+		want interface{}
+		wantErr error
+	}{
+		{"value", valueJob(7), 7, nil},
+		{"error", errJob(errTest), nil, errTest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tp := startPool(t, WithWorkers(1), WithResults(1))
+
+			h := mustSubmit(t, tp, tt.job)
+			var js JobStatus
+			select {
+				case js = <-tp.Results():
+				case <-time.After(testWait):
+					t.Fatal("no execution status on Results()")
+			}
+			if (js.GetJobID() != h.GetID()) || (js.GetJobName() != h.GetName()) {
+				t.Fatalf("status of job %d %q, want %d %q", js.GetJobID(), js.GetJobName(), h.GetID(), h.GetName())
+			}
+			if (js.GetData() != tt.want) || !errors.Is(js.GetError(), tt.wantErr) {
+				t.Fatalf("Results(): %v, %v, want %v, %v", js.GetData(), js.GetError(), tt.want, tt.wantErr)
+			}
+
+			v, err := wait(t, h)
+			if (v != tt.want) || !errors.Is(err, tt.wantErr) || ((err == nil) != (tt.wantErr == nil)) {
+				t.Fatalf("Wait(): %v, %v, want %v, %v", v, err, tt.want, tt.wantErr)
+			}
+			if st, ok := h.Status(); !ok || (st.GetData() != tt.want) || (st.GetAttempts() != 1) {
+				t.Fatalf("Status(): %+v, %t", st, ok)
+			}
+		})
+	}
+}
+
+
+func TestResultsDisabled(t *testing.T) {
+	tp := startPool(t, WithWorkers(1))
+	if tp.Results() != nil {
+		t.Fatal("Results() isn't nil without WithResults()")
+	}
+
+	started, release := make(chan struct{}), make(chan struct{})
+	h := mustSubmit(t, tp, blockJob(started, release))
+	<-started
+	if _, ok := h.Status(); ok {
+		t.Fatal("Status() of a running job is final")
+	}
+	close(release)
+	if _, err := wait(t, h); err != nil {
+		t.Fatalf("job: %v", err)
+	}
+}
+
+
+// result with its own post-processing.
+type processedResult struct {
+	v int
+	err error
+	release <-chan struct{}  // ProcessResult() blocks until it's closed, if set.
+}
+
+func (r processedResult) ProcessResult(ctx context.Context) (interface{}, error) {
+	if r.release != nil {
+		select {
+			case <-r.release:
+			case <-ctx.Done():
+				return nil, ctx.Err()
+		}
+	}
+	return r.v * 10, r.err
+}
+
+
+func TestResultProcessor(t *testing.T) {
+	tests := []struct {
+		name string
+		res processedResult
+		err error  // error of Process().
+		wantData interface{}
+		wantErr error
+		wantHook bool  // OnResultError() is invoked.
+	}{
+		{"processed", processedResult{v: 2}, nil, 20, nil, false},
+		{"processing failed", processedResult{v: 2, err: errTest}, nil, 20, errTest, true},
+		{"not processed once the job failed", processedResult{v: 2, err: errTest}, errTest, nil, errTest, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var failed []error
+			tp := startPool(t, WithWorkers(1), WithOnResultError(func(_ Job, err error) {
+				mu.Lock()
+				failed = append(failed, err)
+				mu.Unlock()
+			}))
+
+			res, perr := tt.res, tt.err
+			h := mustSubmit(t, tp, Func("result", func(context.Context) (interface{}, error) {
+				return res, perr
+			}))
+			_, err := wait(t, h)
+			if !errors.Is(err, tt.wantErr) || ((err == nil) != (tt.wantErr == nil)) {
+				t.Fatalf("Wait(): %v, want %v", err, tt.wantErr)
+			}
+
+			js, _ := h.Status()
+			if js.GetResultData() != tt.wantData {
+				t.Fatalf("GetResultData() = %v, want %v", js.GetResultData(), tt.wantData)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if (len(failed) == 1) != tt.wantHook {
+				t.Fatalf("OnResultError() invoked with %v", failed)
+			}
+		})
+	}
+}
+
+
+func TestResultProcessorOwnWorkers(t *testing.T) {
+	tp := startPool(t, WithWorkers(1), WithResultWorkers(1))
+
+	release := make(chan struct{})
+	slow := mustSubmit(t, tp, valueJob(processedResult{v: 1, release: release}))
+
+	// the slow result holds up neither the worker nor the next job.
+	if v, err := wait(t, mustSubmit(t, tp, valueJob(3))); (err != nil) || (v != 3) {
+		t.Fatalf("next job: %v, %v", v, err)
+	}
+	if _, ok := slow.Status(); ok {
+		t.Fatal("job delivered before its result is processed")
+	}
+
+	close(release)
+	wait(t, slow)
+	if js, _ := slow.Status(); js.GetResultData() != 10 {
+		t.Fatalf("GetResultData() = %v, want 10", js.GetResultData())
+	}
+}
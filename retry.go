@@ -0,0 +1,183 @@
+/* *****************************************************************************
+Copyright (c) 2023, sameeroak1110 (sameeroak1110@gmail.com)
+BSD 3-Clause License.
+
+Package     : github.com/sameeroak1110/gowp
+Filename    : github.com/sameeroak1110/gowp/retry.go
+File-type   : GoLang source code file
+
+Compiler/Runtime: go version go1.20.5 linux/amd64
+
+Version History
+Version     : 1.0
+Author      : Sameer Oak (sameeroak1110@gmail.com)
+
+Description :
+- Retry policies. A job whose Process() method returns an error is retried as per its policy,
+with exponential backoff and jitter in between the attempts.
+***************************************************************************** */
+package gowp
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"time"
+)
+
+const defaultBackoffMultiplier float64 = 2
+
+// - RetryPolicy decides whether and when a failed job is attempted again.
+// - Backoff before attempt n+1 is InitialBackoff * Multiplier^(n-1), capped at MaxBackoff, and
+// then randomized by +/- Jitter fraction of it.
+// - A worker-pool wide policy is set through WorkerPoolOptions.RetryPolicy. A JobProcessor may have
+// its own by implementing Retryer.
+type RetryPolicy struct {
+	MaxAttempts    int              // maximum no. of attempts, including the first one. less than 2 means no retry.
+	InitialBackoff time.Duration    // backoff before the second attempt.
+	MaxBackoff     time.Duration    // upper limit of backoff. 0 means no limit.
+	Multiplier     float64          // backoff growth factor. default is 2.
+	Jitter         float64          // 0 to 1, fraction of backoff that's randomized.
+	RetryOn        func(error) bool // optional, true if the error is worth a retry. default retries any error
+	                                // except a *PanicError and context.Canceled.
+}
+
+type attemptKeyType struct{}
+
+// context key of the attempt no. of a job.
+var attemptKey attemptKeyType
+
+
+/* *****************************************************************************
+Description : Returns the attempt no. of the job the context is passed to.
+
+Arguments   :
+1> ctx context.Context: Context passed to Process() method of a job.
+
+Return value:
+1> int: Attempt no., the first attempt is 1. 0 if ctx isn't a job context.
+
+Additional note: NA
+***************************************************************************** */
+func AttemptFromContext(ctx context.Context) int {
+	attempt, _ := ctx.Value(attemptKey).(int)
+	return attempt
+}
+
+
+// RetryOnErrors returns a RetryOn predicate that's true if the error matches, as per errors.Is(),
+// any of the targets.
+func RetryOnErrors(targets ...error) func(error) bool {
+	return func(err error) bool {
+		for _, target := range targets {
+			if errors.Is(err, target) {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+
+// RetryOnType returns a RetryOn predicate that's true if the error has, as per errors.As(), an
+// error of type T in its chain.
+func RetryOnType[T error]() func(error) bool {
+	return func(err error) bool {
+		var target T
+		return errors.As(err, &target)
+	}
+}
+
+
+// true if the job is to be attempted again after the attempt that failed with err.
+func (rp *RetryPolicy) retries(attempt int, err error) bool {
+	if (rp == nil) || (err == nil) || (attempt >= rp.MaxAttempts) {
+		return false
+	}
+
+	if rp.RetryOn != nil {
+		return rp.RetryOn(err)
+	}
+
+	var pe *PanicError
+	return !errors.As(err, &pe) && !errors.Is(err, context.Canceled)
+}
+
+
+// backoff before the attempt that follows the given attempt.
+func (rp *RetryPolicy) backoff(attempt int) time.Duration {
+	multiplier := rp.Multiplier
+	if multiplier <= 0 {
+		multiplier = defaultBackoffMultiplier
+	}
+
+	d := float64(rp.InitialBackoff) * math.Pow(multiplier, float64(attempt - 1))
+	if (rp.MaxBackoff > 0) && (d > float64(rp.MaxBackoff)) {
+		d = float64(rp.MaxBackoff)
+	}
+
+	if rp.Jitter > 0 {
+		jitter := math.Min(rp.Jitter, 1)
+		d += d * jitter * (rand.Float64() * 2 - 1)
+	}
+
+	if d > math.MaxInt64 {
+		return time.Duration(math.MaxInt64)
+	}
+
+	return time.Duration(d)
+}
+
+
+// retry policy of the job. job's own policy, through Retryer, precedes the worker-pool policy.
+func (pwp *WorkerPool) retryPolicyOf(job Job) *RetryPolicy {
+	if r, ok := unwrapJob(job.data).(Retryer); ok {
+		if rp := r.RetryPolicy(); rp != nil {
+			return rp
+		}
+	}
+
+	return pwp.retryPolicy
+}
+
+
+/* *****************************************************************************
+Description : Runs the job, and runs it again as per its retry policy if it fails.
+
+Receiver    : *WorkerPool
+
+Implements  : NA
+
+Arguments   :
+1> ctx context.Context: Context the job is run with, see beginRun().
+2> job Job: Job to be run.
+
+Return value:
+1> JobStatus: Execution status of the last attempt.
+
+Additional note:
+The worker stays assigned to the job during backoff. Retries stop on cancellation of the
+job context, the status then has the context error, see endRun(), rather than the last
+attempt's error.
+***************************************************************************** */
+func (pwp *WorkerPool) runWithRetry(ctx context.Context, job Job) JobStatus {
+	rp := pwp.retryPolicyOf(job)
+
+	for attempt := 1; ; attempt++ {
+		js := pwp.run(ctx, job, attempt)
+		if !rp.retries(attempt, js.err) {
+			return js
+		}
+
+		timer := time.NewTimer(rp.backoff(attempt))
+		select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				js.data, js.err = nil, ctx.Err()
+				return js
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package gowp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	ms := time.Millisecond
+	tests := []struct {
+		name string
+		rp RetryPolicy
+		attempt int
+		min, max time.Duration
+	}{
+		{"first", RetryPolicy{InitialBackoff: 10 * ms}, 1, 10 * ms, 10 * ms},
+		{"doubles by default", RetryPolicy{InitialBackoff: 10 * ms}, 3, 40 * ms, 40 * ms},
+		{"multiplier", RetryPolicy{InitialBackoff: 10 * ms, Multiplier: 3}, 3, 90 * ms, 90 * ms},
+		{"capped", RetryPolicy{InitialBackoff: 10 * ms, MaxBackoff: 25 * ms}, 3, 25 * ms, 25 * ms},
+		{"jitter", RetryPolicy{InitialBackoff: 100 * ms, Jitter: 0.2}, 1, 80 * ms, 120 * ms},
+		{"jitter over 1", RetryPolicy{InitialBackoff: 100 * ms, Jitter: 5}, 1, 0, 200 * ms},
+		{"no overflow", RetryPolicy{InitialBackoff: time.Hour}, 200, time.Duration(1 << 62), time.Duration(1<<63 - 1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < 100; i++ {
+				if d := tt.rp.backoff(tt.attempt); (d < tt.min) || (d > tt.max) {
+					t.Fatalf("backoff(%d) = %s, want within [%s, %s]", tt.attempt, d, tt.min, tt.max)
+				}
+			}
+		})
+	}
+}
+
+
+func TestRetries(t *testing.T) {
+	errRetry := errors.New("retry me")
+	tests := []struct {
+		name string
+		rp *RetryPolicy
+		attempt int
+		err error
+		want bool
+	}{
+		{"no policy", nil, 1, errTest, false},
+		{"succeeded", &RetryPolicy{MaxAttempts: 3}, 1, nil, false},
+		{"any error", &RetryPolicy{MaxAttempts: 3}, 1, errTest, true},
+		{"attempts exhausted", &RetryPolicy{MaxAttempts: 3}, 3, errTest, false},
+		{"panic", &RetryPolicy{MaxAttempts: 3}, 1, &PanicError{Value: "boom"}, false},
+		{"cancelled", &RetryPolicy{MaxAttempts: 3}, 1, fmt.Errorf("%w: %w", ErrJobCancelled, context.Canceled), false},
+		{"timed out", &RetryPolicy{MaxAttempts: 3}, 1, context.DeadlineExceeded, true},
+		{"retry on match", &RetryPolicy{MaxAttempts: 3, RetryOn: RetryOnErrors(errRetry)}, 1, fmt.Errorf("wrapped: %w", errRetry), true},
+		{"retry on mismatch", &RetryPolicy{MaxAttempts: 3, RetryOn: RetryOnErrors(errRetry)}, 1, errTest, false},
+		{"retry on type", &RetryPolicy{MaxAttempts: 3, RetryOn: RetryOnType[*PanicError]()}, 1, &PanicError{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rp.retries(tt.attempt, tt.err); got != tt.want {
+				t.Fatalf("retries(%d, %v) = %t, want %t", tt.attempt, tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+
+// job with its own retry policy, fails its first n attempts.
+type retryerJob struct {
+	n int32
+	attempts *int32
+	rp *RetryPolicy
+}
+
+func (j retryerJob) GetName() string {
+	return "retryer"
+}
+
+func (j retryerJob) Process(ctx context.Context, _ context.CancelFunc, _ int, _ bool) (interface{}, error) {
+	if atomic.AddInt32(j.attempts, 1) <= j.n {
+		return nil, errTest
+	}
+	return AttemptFromContext(ctx), nil
+}
+
+func (j retryerJob) RetryPolicy() *RetryPolicy {
+	return j.rp
+}
+
+
+func TestRetryRun(t *testing.T) {
+	pool := &RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}
+	tests := []struct {
+		name string
+		fails int32
+		own *RetryPolicy
+		attempts int
+		wantErr bool
+	}{
+		{"succeeds on a retry", 2, nil, 3, false},
+		{"exhausted", 5, nil, 3, true},
+		{"own policy", 4, &RetryPolicy{MaxAttempts: 5, InitialBackoff: time.Millisecond}, 5, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tp := startPool(t, WithWorkers(1), WithRetryPolicy(pool))
+
+			var n int32
+			h := mustSubmit(t, tp, retryerJob{n: tt.fails, attempts: &n, rp: tt.own})
+			v, err := wait(t, h)
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("job: %v, %v", v, err)
+			}
+			if !tt.wantErr && (v != tt.attempts) {
+				t.Fatalf("succeeded on attempt %v, want %d", v, tt.attempts)
+			}
+			if ji, _ := tp.JobInfo(h.GetID()); ji.Attempts != tt.attempts {
+				t.Fatalf("%d attempts, want %d", ji.Attempts, tt.attempts)
+			}
+		})
+	}
+}
+
+
+func TestRetryBackoffCancelled(t *testing.T) {
+	tp := startPool(t, WithWorkers(1), WithRetryPolicy(&RetryPolicy{MaxAttempts: 5, InitialBackoff: time.Hour}))
+
+	var n int32
+	h := mustSubmit(t, tp, retryerJob{n: 10, attempts: &n})
+	waitFor(t, "first attempt", func() bool {
+		return atomic.LoadInt32(&n) == 1
+	})
+	tp.Cancel(h.GetID())
+
+	if _, err := wait(t, h); err == nil {
+		t.Fatal("job cancelled during backoff succeeded")
+	}
+	if m := atomic.LoadInt32(&n); m != 1 {
+		t.Fatalf("%d attempts after cancellation during backoff, want 1", m)
+	}
+}
@@ -6,3 +6,5 @@ require (
 	github.com/sameeroak1110/gowp v1.0.0
 	github.com/sameeroak1110/logger v1.0.19
 )
+
+replace github.com/sameeroak1110/gowp => ../
@@ -5,6 +5,7 @@ import (
 	"context"
 
 	"github.com/sameeroak1110/logger"
+	"github.com/sameeroak1110/gowp"
 	"github.com/sameeroak1110/gowp/helper"
 )
 
@@ -20,7 +21,8 @@ func (job TestJobData) GetName() string {
 	return job.Name
 }
 
-func (job TestJobData) Process(ctx context.Context, cancelFunc context.CancelFunc, cnt int, shouldTerminate bool) (interface{}, error) {
+// the worker-pool shuts down by itself as per its termination policy, thus the job doesn't need to.
+func (job TestJobData) Process(ctx context.Context, ctl gowp.JobControl) (interface{}, error) {
 	defer func() {
 		if panicState := recover(); panicState != nil {
 			logger.Log(pkgname, logger.ERROR, "Recovered from panic. state: %#v", panicState)
@@ -41,13 +43,8 @@ func (job TestJobData) Process(ctx context.Context, cancelFunc context.CancelFun
 			//execForMS := helper.RandomInt(5000, 8000)
 			execForMS := helper.RandomInt(3000, 5000)
 			time.Sleep(time.Duration(execForMS) * time.Millisecond)
-			logger.Log(pkgname, logger.DEBUG, "TestJobData process(%d:%s) executed for %d ms", job.ID, job.Name, execForMS)
-			if shouldTerminate == true {
-				if job.ID == int64(cnt) {
-					logger.Log(pkgname, logger.DEBUG, "All done. Invoking termination.")
-					cancelFunc()
-				}
-			}
+			logger.Log(pkgname, logger.DEBUG, "TestJobData process(%d:%s) executed for %d ms (job %d, attempt %d)", job.ID, job.Name,
+				execForMS, ctl.JobID(), ctl.Attempt())
 	}
 
 	return nil, nil
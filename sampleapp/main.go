@@ -46,7 +46,12 @@ func addjobs(ctx context.Context, pwg *sync.WaitGroup, pwp *gowp.WorkerPool) {
 					Name: fmt.Sprintf("TestJob-%d", i),
 				}
 				time.Sleep(time.Duration(waitForMS) * time.Millisecond)
-				pwp.AddJob(job)
+				if err := pwp.Submit(ctx, gowp.V2(job)); err != nil {
+					// ctx is cancelled or the worker-pool is stopped, no point adding more jobs.
+					logger.Log(pkgname, logger.WARNING, "[%s:%d]  job(%s:%d) not added: %s\n", pwp.GetName(), pwp.GetID(),
+						job.Name, job.ID, err.Error())
+					return
+				}
 				logger.Log(pkgname, logger.DEBUG, "[%s:%d]  waited for %d ms before new job(%s:%d) was added\n", pwp.GetName(), pwp.GetID(),
 					waitForMS, job.Name, job.ID)
 				i++
@@ -76,7 +81,9 @@ func main() {
 	logger.Log(pkgname, logger.DEBUG, "log dispatcher started.")
 
 	//pwp, _, err := gowp.NewWorkerPool(ctxParent, cancelParent, 100, "wp1", "started wp-1", "cancelled wp-1")
-	pwp, _, err := gowp.NewWorkerPool(ctxParent, cancelParent, 100, "wp1", "started wp-1", "cancelled wp-1", gowp.WorkerPoolOptions{1000, true})
+	// worker-pool shuts down gracefully once 1000 jobs have finished.
+	pwp, _, err := gowp.NewWorkerPool(ctxParent, cancelParent, 100, "wp1", "started wp-1", "cancelled wp-1",
+		gowp.WorkerPoolOptions{Terminate: []gowp.TerminationPolicy{gowp.AfterCompleted(1000)}})
 	if err != nil {
 		logger.Log(pkgname, logger.ERROR, "new worker-pool error: %s\n", err.Error())
 		return
@@ -96,6 +103,13 @@ func main() {
 		// pReader.ReadString('\n')
 		//fmt.Println("WARNING: received termination.")
 		logger.Log(pkgname, logger.WARNING, "Received termination.")
+
+		// lets the queued jobs finish, for at most 30 seconds.
+		ctxShutdown, cancelShutdown := context.WithTimeout(ctxParent, 30 * time.Second)
+		defer cancelShutdown()
+		if jobs, err := pwp.Shutdown(ctxShutdown); err != nil {
+			logger.Log(pkgname, logger.WARNING, "worker-pool shutdown: %s, %d jobs not run.", err.Error(), len(jobs))
+		}
 		cancelParent()
 	}()
 
@@ -0,0 +1,359 @@
+/* *****************************************************************************
+Copyright (c) 2023, sameeroak1110 (sameeroak1110@gmail.com)
+BSD 3-Clause License.
+
+Package     : github.com/sameeroak1110/gowp
+Filename    : github.com/sameeroak1110/gowp/schedule.go
+File-type   : GoLang source code file
+
+Compiler/Runtime: go version go1.20.5 linux/amd64
+
+Version History
+Version     : 1.0
+Author      : Sameer Oak (sameeroak1110@gmail.com)
+
+Description :
+- Delayed and scheduled jobs, added through SubmitAfter() and SubmitAt().
+- Scheduled jobs are kept in a binary heap ordered by their due time. A scheduler go-routine,
+started along with the first scheduled job, sleeps until the earliest due time and then pushes
+the due jobs to the job queue. It lasts as long as the worker-pool context.
+- The scheduler never waits for room in the job queue, a due job that doesn't fit is scheduled
+again a little later. Thus, a full job queue doesn't hold back the rest of the due jobs.
+- A scheduled job can be cancelled until it's due.
+***************************************************************************** */
+package gowp
+
+import (
+	"container/heap"
+	"errors"
+	"sort"
+	"time"
+)
+
+const schedRetryDelay time.Duration = 10 * time.Millisecond  // a due job that doesn't fit in the job queue is retried after these.
+
+var ErrJobCancelled = errors.New("gowp: job has been cancelled")
+
+// a job scheduled to be pushed to the job queue at a later time.
+type scheduledJob struct {
+	job Job
+	at time.Time
+	index int  // index in the heap, maintained by the heap.
+}
+
+// scheduled jobs, implements heap.Interface. earliest due job on the top.
+type scheduleHeap []*scheduledJob
+
+// ScheduledJob describes a job waiting for its due time, see Scheduled().
+type ScheduledJob struct {
+	ID   uint64
+	Name string
+	At   time.Time  // due time.
+}
+
+
+func (h scheduleHeap) Len() int {
+	return len(h)
+}
+
+
+func (h scheduleHeap) Less(i, k int) bool {
+	if !h[i].at.Equal(h[k].at) {
+		return h[i].at.Before(h[k].at)
+	}
+
+	return h[i].job.id < h[k].job.id
+}
+
+
+func (h scheduleHeap) Swap(i, k int) {
+	h[i], h[k] = h[k], h[i]
+	h[i].index = i
+	h[k].index = k
+}
+
+
+func (h *scheduleHeap) Push(x interface{}) {
+	sj := x.(*scheduledJob)
+	sj.index = len(*h)
+	*h = append(*h, sj)
+}
+
+
+func (h *scheduleHeap) Pop() interface{} {
+	old := *h
+	n := len(old) - 1
+	sj := old[n]
+	old[n] = nil  // lets go of the reference.
+	sj.index = -1
+	*h = old[:n]
+
+	return sj
+}
+
+
+/* *****************************************************************************
+Description : Adds a job to the worker-pool to be run after d.
+
+Receiver    : *WorkerPool
+
+Implements  : NA
+
+Arguments   :
+1> d time.Duration: Delay. The job is pushed to the job queue once it's elapsed.
+2> job JobProcessor: Job to be executed.
+3> opts ...SubmitOption: Job options.
+
+Return value:
+1> *JobHandle: Future of the scheduled job, nil if the job couldn't be scheduled.
+2> error: ErrPoolStopped if the worker-pool has been stopped.
+
+Additional note:
+Same as SubmitAt(time.Now().Add(d), job, opts...).
+***************************************************************************** */
+func (pwp *WorkerPool) SubmitAfter(d time.Duration, job JobProcessor, opts ...SubmitOption) (*JobHandle, error) {
+	return pwp.SubmitAt(time.Now().Add(d), job, opts...)
+}
+
+
+/* *****************************************************************************
+Description : Adds a job to the worker-pool to be run at t.
+
+Receiver    : *WorkerPool
+
+Implements  : NA
+
+Arguments   :
+1> t time.Time: Due time. The job is pushed to the job queue once it's due.
+2> job JobProcessor: Job to be executed.
+3> opts ...SubmitOption: Job options.
+
+Return value:
+1> *JobHandle: Future of the scheduled job, nil if the job couldn't be scheduled.
+2> error: ErrPoolStopped if the worker-pool has been stopped.
+
+Additional note:
+- If the job queue is full once the job is due, the job stays scheduled and is retried a little
+later, its due time is moved on then. If it can't be pushed, because the worker-pool has been
+stopped meanwhile, its handle is resolved with the error.
+- Until it's pushed, the job can be cancelled with CancelScheduled().
+***************************************************************************** */
+func (pwp *WorkerPool) SubmitAt(t time.Time, job JobProcessor, opts ...SubmitOption) (*JobHandle, error) {
+	j := pwp.newJob(job, opts...)
+
+	// checked and scheduled under the same lock, thus Shutdown() either finds the job scheduled or
+	// it's rejected.
+	pwp.qmu.Lock()
+	if pwp.qclosed {
+		pwp.qmu.Unlock()
+		return nil, ErrPoolStopped
+	}
+	pwp.register(j, JobScheduled)
+	pwp.schedule(j, t)
+	pwp.qmu.Unlock()
+
+	pwp.sonce.Do(func() {
+		go pwp.runScheduler()
+	})
+
+	// wakes up the scheduler as the new job may be the earliest.
+	select {
+		case pwp.swake <- struct{}{}:
+		default:
+	}
+
+	return j.handle, nil
+}
+
+
+/* *****************************************************************************
+Description : Cancels a scheduled job that isn't due yet.
+
+Receiver    : *WorkerPool
+
+Implements  : NA
+
+Arguments   :
+1> h *JobHandle: Handle returned by SubmitAfter() or SubmitAt().
+
+Return value:
+1> bool: true if the job has been cancelled. false if it's already been pushed to the job queue.
+
+Additional note:
+Handle of the cancelled job is resolved with ErrJobCancelled.
+***************************************************************************** */
+func (pwp *WorkerPool) CancelScheduled(h *JobHandle) bool {
+	if h == nil {
+		return false
+	}
+
+	pwp.smu.Lock()
+	sj, ok := pwp.sbyID[h.GetID()]
+	if ok {
+		heap.Remove(&pwp.sched, sj.index)
+		delete(pwp.sbyID, h.GetID())
+	}
+	pwp.smu.Unlock()
+
+	if !ok {
+		return false
+	}
+	pwp.resolve(sj.job, JobStatus{id: sj.job.id, name: sj.job.name, err: ErrJobCancelled})
+
+	return true
+}
+
+
+// Scheduled returns the jobs waiting for their due time, earliest first.
+func (pwp *WorkerPool) Scheduled() []ScheduledJob {
+	pwp.smu.Lock()
+	sjobs := make([]ScheduledJob, 0, len(pwp.sched))
+	for _, sj := range pwp.sched {
+		sjobs = append(sjobs, ScheduledJob{ID: sj.job.id, Name: sj.job.name, At: sj.at})
+	}
+	pwp.smu.Unlock()
+
+	sort.Slice(sjobs, func(i, k int) bool {
+		if !sjobs[i].At.Equal(sjobs[k].At) {
+			return sjobs[i].At.Before(sjobs[k].At)
+		}
+		return sjobs[i].ID < sjobs[k].ID
+	})
+
+	return sjobs
+}
+
+
+// removes the scheduled jobs for which pred is true. smu must be held.
+func (pwp *WorkerPool) removeScheduledWhere(pred func(Job) bool) {
+	n := 0
+	for _, sj := range pwp.sched {
+		if pred(sj.job) {
+			delete(pwp.sbyID, sj.job.id)
+			continue
+		}
+		pwp.sched[n] = sj
+		n++
+	}
+
+	if n == len(pwp.sched) {
+		return
+	}
+
+	for i := n; i < len(pwp.sched); i++ {
+		pwp.sched[i] = nil  // lets go of the reference.
+	}
+	pwp.sched = pwp.sched[:n]
+	for i, sj := range pwp.sched {
+		sj.index = i
+	}
+	heap.Init(&pwp.sched)
+
+	return
+}
+
+
+// adds the job to the heap.
+func (pwp *WorkerPool) schedule(j Job, t time.Time) {
+	pwp.smu.Lock()
+	sj := &scheduledJob{job: j, at: t}
+	heap.Push(&pwp.sched, sj)
+	pwp.sbyID[j.id] = sj
+	pwp.smu.Unlock()
+}
+
+
+// pops the jobs due by now.
+func (pwp *WorkerPool) popDue() ([]Job, time.Duration) {
+	pwp.smu.Lock()
+	defer pwp.smu.Unlock()
+
+	var due []Job
+	now := time.Now()
+	for len(pwp.sched) > 0 {
+		if pwp.sched[0].at.After(now) {
+			return due, pwp.sched[0].at.Sub(now)
+		}
+		sj := heap.Pop(&pwp.sched).(*scheduledJob)
+		delete(pwp.sbyID, sj.job.id)
+		due = append(due, sj.job)
+	}
+
+	return due, -1  // nothing else is scheduled.
+}
+
+
+// pops all the scheduled jobs without pushing them to the job queue.
+func (pwp *WorkerPool) takeScheduled() []Job {
+	pwp.smu.Lock()
+	defer pwp.smu.Unlock()
+
+	var jobs []Job
+	for len(pwp.sched) > 0 {
+		sj := heap.Pop(&pwp.sched).(*scheduledJob)
+		delete(pwp.sbyID, sj.job.id)
+		jobs = append(jobs, sj.job)
+	}
+
+	return jobs
+}
+
+
+/* *****************************************************************************
+Description : Runs the scheduler. Invoked as a go-routine along with the first scheduled job.
+
+Receiver    : *WorkerPool
+
+Implements  : NA
+
+Arguments   : NA
+
+Return value: NA
+
+Additional note:
+- Once the worker-pool context is done, handles of the jobs still scheduled are resolved with the
+context error.
+- A due job that doesn't fit in the job queue is scheduled again after schedRetryDelay.
+***************************************************************************** */
+func (pwp *WorkerPool) runScheduler() {
+	ctx := pwp.GetContext()
+	for {
+		due, wait := pwp.popDue()
+		for _, j := range due {
+			j.submittedAt = time.Now()  // queue wait starts now.
+			err := pwp.enqueue(ctx, j, false)
+			switch {
+				case err == nil:
+				case errors.Is(err, ErrQueueFull), errors.Is(err, ErrTenantQueueFull):
+					pwp.schedule(j, time.Now().Add(schedRetryDelay))
+				default:
+					pwp.resolve(j, JobStatus{id: j.id, name: j.name, err: err})
+			}
+		}
+
+		if len(due) > 0 {
+			continue  // more jobs may have become due meanwhile. those scheduled again aren't due yet.
+		}
+
+		var t *time.Timer
+		var timer <-chan time.Time
+		if wait >= 0 {
+			t = time.NewTimer(wait)
+			timer = t.C
+		}
+
+		select {
+			case <-timer:
+			case <-pwp.swake:
+			case <-ctx.Done():
+				for _, j := range pwp.takeScheduled() {
+					pwp.resolve(j, JobStatus{id: j.id, name: j.name, err: ctx.Err()})
+				}
+				return
+		}
+
+		if t != nil {
+			t.Stop()
+		}
+	}
+}
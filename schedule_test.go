@@ -0,0 +1,139 @@
+package gowp
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSubmitAfterOrder(t *testing.T) {
+	tp := startPool(t, WithWorkers(1))
+
+	var mu sync.Mutex
+	var order []int
+	delays := []time.Duration{30 * time.Millisecond, 10 * time.Millisecond, 20 * time.Millisecond, 10 * time.Millisecond}
+	var hs []*JobHandle
+	for i, d := range delays {
+		i := i
+		job := Func("delayed", func(context.Context) (interface{}, error) {
+			mu.Lock()
+			order = append(order, i)
+			mu.Unlock()
+			return nil, nil
+		})
+		h, err := tp.SubmitAfter(d, job)
+		if err != nil {
+			t.Fatalf("SubmitAfter(): %v", err)
+		}
+		hs = append(hs, h)
+	}
+	if n := len(tp.Scheduled()); n != len(delays) {
+		t.Fatalf("%d jobs scheduled, want %d", n, len(delays))
+	}
+
+	for _, h := range hs {
+		if _, err := wait(t, h); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	want := []int{1, 3, 2, 0}  // by due time, then by submission.
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("jobs ran in order %v, want %v", order, want)
+		}
+	}
+}
+
+
+func TestCancelScheduled(t *testing.T) {
+	tp := startPool(t, WithWorkers(1))
+
+	h, err := tp.SubmitAfter(time.Hour, valueJob(1))
+	if err != nil {
+		t.Fatalf("SubmitAfter(): %v", err)
+	}
+	if !tp.CancelScheduled(h) {
+		t.Fatal("CancelScheduled() = false for a job that isn't due")
+	}
+	if _, err := wait(t, h); !errors.Is(err, ErrJobCancelled) {
+		t.Fatalf("job: %v, want ErrJobCancelled", err)
+	}
+	if tp.CancelScheduled(h) {
+		t.Fatal("CancelScheduled() = true for a cancelled job")
+	}
+	if n := len(tp.Scheduled()); n != 0 {
+		t.Fatalf("%d jobs scheduled, want none", n)
+	}
+
+	h, _ = tp.SubmitAfter(0, valueJob(2))
+	wait(t, h)
+	if tp.CancelScheduled(h) {
+		t.Fatal("CancelScheduled() = true for a job that's been run")
+	}
+}
+
+
+func TestScheduledShutdown(t *testing.T) {
+	tp := startPool(t, WithWorkers(1))
+
+	h, err := tp.SubmitAfter(time.Hour, valueJob(1))
+	if err != nil {
+		t.Fatalf("SubmitAfter(): %v", err)
+	}
+	jobs, err := tp.Shutdown(context.Background())
+	if err != nil {
+		t.Fatalf("Shutdown(): %v", err)
+	}
+	if (len(jobs) != 1) || (jobs[0].GetID() != h.GetID()) {
+		t.Fatalf("Shutdown() returned %d jobs, want the scheduled one", len(jobs))
+	}
+	if _, err := wait(t, h); !errors.Is(err, ErrPoolStopped) {
+		t.Fatalf("job: %v, want ErrPoolStopped", err)
+	}
+
+	if _, err := tp.SubmitAfter(0, valueJob(2)); !errors.Is(err, ErrPoolStopped) {
+		t.Fatalf("SubmitAfter() after Shutdown(): %v, want ErrPoolStopped", err)
+	}
+}
+
+
+func TestScheduledQueueFull(t *testing.T) {
+	tp := startPool(t, WithWorkers(1), WithFairQueue(FairQueueOptions{Limits: map[string]int{"a": 1}}))
+
+	started, release := make(chan struct{}), make(chan struct{})
+	if err := tp.Submit(context.Background(), blockJob(started, release)); err != nil {
+		t.Fatalf("Submit(): %v", err)
+	}
+	<-started
+	if err := tp.TrySubmit(valueJob(nil), WithTenant("a")); err != nil {
+		t.Fatalf("TrySubmit(): %v", err)
+	}
+
+	// the job of tenant a doesn't fit, the later one of tenant b isn't held back by it.
+	ha, err := tp.SubmitAfter(0, valueJob("a"), WithTenant("a"))
+	if err != nil {
+		t.Fatalf("SubmitAfter(): %v", err)
+	}
+	hb, err := tp.SubmitAfter(5 * time.Millisecond, valueJob("b"), WithTenant("b"))
+	if err != nil {
+		t.Fatalf("SubmitAfter(): %v", err)
+	}
+	waitFor(t, "job of tenant b queued", func() bool {
+		ji, _ := tp.JobInfo(hb.GetID())
+		return ji.State == JobQueued
+	})
+	if ji, _ := tp.JobInfo(ha.GetID()); ji.State != JobScheduled {
+		t.Fatalf("job of tenant a %s, want it still scheduled", ji.State)
+	}
+
+	close(release)
+	for _, h := range []*JobHandle{ha, hb} {
+		if _, err := wait(t, h); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
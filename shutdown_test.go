@@ -0,0 +1,94 @@
+package gowp
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestShutdown(t *testing.T) {
+	tests := []struct {
+		name string
+		start bool
+		pause bool
+		release bool           // the running job finishes while the worker-pool drains.
+		timeout time.Duration  // Shutdown() context timeout, none if 0.
+		wantLeft int           // jobs Shutdown() returns.
+		wantErr error
+	}{
+		{"drains the queue", true, false, true, 0, 0, nil},
+		{"drains a paused pool", true, true, true, 0, 0, nil},
+		{"context done", true, false, false, 20 * time.Millisecond, 3, context.DeadlineExceeded},
+		{"never started", false, false, false, 0, 3, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tp := newPool(t, WithWorkers(1))
+			if tt.start {
+				tp.start(t)
+			}
+
+			started, release := make(chan struct{}), make(chan struct{})
+			var blocker *JobHandle
+			if tt.start {
+				blocker = mustSubmit(t, tp, blockJob(started, release))
+				<-started
+			}
+			if tt.pause {
+				if err := tp.Pause(); err != nil {
+					t.Fatalf("Pause(): %v", err)
+				}
+			}
+			var hs []*JobHandle
+			for i := 0; i < 3; i++ {
+				hs = append(hs, mustSubmit(t, tp, valueJob(i)))
+			}
+
+			ctx := context.Background()
+			if tt.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+			if tt.release {
+				close(release)
+			}
+			left, err := tp.Shutdown(ctx)
+			if !errors.Is(err, tt.wantErr) || ((err == nil) != (tt.wantErr == nil)) {
+				t.Fatalf("Shutdown(): %v, want %v", err, tt.wantErr)
+			}
+			if len(left) != tt.wantLeft {
+				t.Fatalf("Shutdown() returned %d jobs, want %d", len(left), tt.wantLeft)
+			}
+			if !tt.release {
+				close(release)  // running jobs aren't affected by Shutdown() context.
+			}
+
+			for i, h := range hs {
+				v, err := wait(t, h)
+				if tt.wantLeft > 0 {
+					if !errors.Is(err, ErrPoolStopped) {
+						t.Fatalf("job left in the queue: %v, want ErrPoolStopped", err)
+					}
+				} else if (err != nil) || (v != i) {
+					t.Fatalf("drained job: %v, %v", v, err)
+				}
+			}
+			if blocker != nil {
+				if _, err := wait(t, blocker); err != nil {
+					t.Fatalf("running job: %v", err)
+				}
+			}
+
+			tp.waitStart(t)
+			if s := tp.State(); s != StateStopped {
+				t.Fatalf("state %s once shut down, want stopped", s)
+			}
+			if err := tp.TrySubmit(valueJob(0)); !errors.Is(err, ErrPoolStopped) {
+				t.Fatalf("TrySubmit() once shut down: %v, want ErrPoolStopped", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,222 @@
+/* *****************************************************************************
+Copyright (c) 2023, sameeroak1110 (sameeroak1110@gmail.com)
+BSD 3-Clause License.
+
+Package     : github.com/sameeroak1110/gowp
+Filename    : github.com/sameeroak1110/gowp/state.go
+File-type   : GoLang source code file
+
+Compiler/Runtime: go version go1.20.5 linux/amd64
+
+Version History
+Version     : 1.0
+Author      : Sameer Oak (sameeroak1110@gmail.com)
+
+Description :
+- Worker-pool life-cycle states and transitions.
+- Created --Start()--> Running --Pause()--> Paused --Resume()--> Running
+- Running/Paused --Shutdown()--> Draining --(no more queued jobs)--> Stopped
+- Running/Paused/Draining --Stop() or context cancellation--> Stopped
+- Stopped --Start()--> Running
+***************************************************************************** */
+package gowp
+
+import (
+	"context"
+)
+
+type PoolState int32
+
+const (
+	StateCreated PoolState = iota  // created, not yet started. jobs are accepted and queued.
+	StateRunning                   // workers are executing the queued jobs.
+	StatePaused                    // jobs are accepted and queued, however, not executed until resumed.
+	StateDraining                  // no more jobs are accepted, workers are executing the queued jobs.
+	StateStopped                   // no more jobs are accepted or executed. may be started again.
+)
+
+
+func (s PoolState) String() string {
+	switch s {
+		case StateCreated:
+			return "created"
+		case StateRunning:
+			return "running"
+		case StatePaused:
+			return "paused"
+		case StateDraining:
+			return "draining"
+		case StateStopped:
+			return "stopped"
+	}
+
+	return "unknown"
+}
+
+
+// State returns the current life-cycle state of the worker-pool.
+func (pwp *WorkerPool) State() PoolState {
+	pwp.singletonCtrl.Lock()
+	defer pwp.singletonCtrl.Unlock()
+
+	return pwp.state
+}
+
+
+// notifies the state change to WorkerPoolOptions.OnStateChange, if set. must be invoked without
+// holding singletonCtrl so that the callback may use the worker-pool.
+func (pwp *WorkerPool) notifyState(from, to PoolState) {
+	if (from == to) || (pwp.onStateChange == nil) {
+		return
+	}
+
+	pwp.onStateChange(from, to)
+}
+
+
+/* *****************************************************************************
+Description : Pauses a running worker-pool.
+
+Receiver    : *WorkerPool
+
+Implements  : NA
+
+Arguments   : NA
+
+Return value:
+1> error: ErrPoolNotRunning if the worker-pool is neither running nor paused.
+
+Additional note:
+Jobs are accepted and queued while the worker-pool is paused, however, no job is assigned to a
+worker until it's resumed. Running jobs aren't affected.
+***************************************************************************** */
+func (pwp *WorkerPool) Pause() error {
+	pwp.singletonCtrl.Lock()
+	switch pwp.state {
+		case StatePaused:
+			pwp.singletonCtrl.Unlock()
+			return nil
+
+		case StateRunning:
+			pwp.state = StatePaused
+			pwp.resumed = make(chan struct{})
+			pwp.singletonCtrl.Unlock()
+			pwp.notifyJob()  // Start() may be waiting for a job, it's to wait to be resumed instead.
+
+		default:
+			pwp.singletonCtrl.Unlock()
+			return ErrPoolNotRunning
+	}
+
+	pwp.notifyState(StateRunning, StatePaused)
+
+	return nil
+}
+
+
+/* *****************************************************************************
+Description : Resumes a paused worker-pool.
+
+Receiver    : *WorkerPool
+
+Implements  : NA
+
+Arguments   : NA
+
+Return value:
+1> error: ErrPoolNotRunning if the worker-pool is neither running nor paused.
+
+Additional note: NA
+***************************************************************************** */
+func (pwp *WorkerPool) Resume() error {
+	pwp.singletonCtrl.Lock()
+	switch pwp.state {
+		case StateRunning:
+			pwp.singletonCtrl.Unlock()
+			return nil
+
+		case StatePaused:
+			pwp.state = StateRunning
+			close(pwp.resumed)
+			pwp.singletonCtrl.Unlock()
+
+		default:
+			pwp.singletonCtrl.Unlock()
+			return ErrPoolNotRunning
+	}
+
+	pwp.notifyState(StatePaused, StateRunning)
+
+	return nil
+}
+
+
+// waits while the worker-pool is paused. returns false if ctx or the worker-pool context is done,
+// or the worker-pool is stopped meanwhile.
+func (pwp *WorkerPool) awaitResume(ctx context.Context, quit <-chan struct{}) bool {
+	pwp.singletonCtrl.Lock()
+	resumed := pwp.resumed
+	pwp.singletonCtrl.Unlock()
+
+	// a done context wins over a worker-pool that's not paused.
+	if (ctx.Err() != nil) || (pwp.GetContext().Err() != nil) {
+		return false
+	}
+
+	select {
+		case <-resumed:
+			return true
+
+		case <-ctx.Done():
+			return false
+
+		case <-pwp.GetContext().Done():
+			return false
+
+		case <-quit:
+			return false
+	}
+}
+
+
+// returns a channel that's closed once the worker-pool is stopped. a worker-pool started again
+// has a new one.
+func (pwp *WorkerPool) haltSignal() <-chan struct{} {
+	pwp.singletonCtrl.Lock()
+	defer pwp.singletonCtrl.Unlock()
+
+	return pwp.halted
+}
+
+
+// closes the halt signal, if it isn't already. singletonCtrl must be held.
+func (pwp *WorkerPool) closeHalt() {
+	select {
+		case <-pwp.halted:
+		default:
+			close(pwp.halted)
+	}
+}
+
+
+// replaces the halt signal of a stopped worker-pool that's started again. singletonCtrl must be held.
+func (pwp *WorkerPool) rearmHalt() {
+	select {
+		case <-pwp.halted:
+			pwp.halted = make(chan struct{})
+		default:
+	}
+}
+
+
+// true once ctx is done or quit is closed.
+func isDone(ctx context.Context, quit <-chan struct{}) bool {
+	select {
+		case <-ctx.Done():
+			return true
+		case <-quit:
+			return true
+		default:
+			return false
+	}
+}
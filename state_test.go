@@ -0,0 +1,147 @@
+package gowp
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStartReturns(t *testing.T) {
+	tests := []struct {
+		name string
+		pause bool
+		end func(tp *testPool, cancelStart context.CancelFunc)
+	}{
+		{"pool context cancelled while idle", false, func(tp *testPool, _ context.CancelFunc) { tp.cancel() }},
+		{"pool context cancelled while paused", true, func(tp *testPool, _ context.CancelFunc) { tp.cancel() }},
+		{"start context cancelled while idle", false, func(_ *testPool, cancel context.CancelFunc) { cancel() }},
+		{"start context cancelled while paused", true, func(_ *testPool, cancel context.CancelFunc) { cancel() }},
+		{"stopped while idle", false, func(tp *testPool, _ context.CancelFunc) { tp.Stop() }},
+		{"stopped while paused", true, func(tp *testPool, _ context.CancelFunc) { tp.Stop() }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tp := newPool(t, WithWorkers(2))
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			tp.wg.Add(1)
+			go tp.Start(ctx, &tp.wg)
+			waitFor(t, "worker-pool running", func() bool {
+				return tp.State() == StateRunning
+			})
+			if tt.pause {
+				if err := tp.Pause(); err != nil {
+					t.Fatalf("Pause(): %v", err)
+				}
+			}
+
+			tt.end(tp, cancel)
+			tp.waitStart(t)
+			if s := tp.State(); s != StateStopped {
+				t.Fatalf("state %s, want stopped", s)
+			}
+		})
+	}
+}
+
+
+func TestStateTransitions(t *testing.T) {
+	var mu sync.Mutex
+	var got []PoolState
+	tp := newPool(t, WithWorkers(1), WithOnStateChange(func(_, to PoolState) {
+		mu.Lock()
+		got = append(got, to)
+		mu.Unlock()
+	}))
+
+	if err := tp.Pause(); err != ErrPoolNotRunning {
+		t.Fatalf("Pause() before Start(): %v, want ErrPoolNotRunning", err)
+	}
+
+	tp.start(t)
+	steps := []struct {
+		name string
+		op func() error
+		want PoolState
+	}{
+		{"pause", tp.Pause, StatePaused},
+		{"pause again", tp.Pause, StatePaused},
+		{"resume", tp.Resume, StateRunning},
+		{"resume again", tp.Resume, StateRunning},
+	}
+	for _, st := range steps {
+		if err := st.op(); err != nil {
+			t.Fatalf("%s: %v", st.name, err)
+		}
+		if s := tp.State(); s != st.want {
+			t.Fatalf("%s: state %s, want %s", st.name, s, st.want)
+		}
+	}
+
+	tp.Stop()
+	tp.waitStart(t)
+	if err := tp.Resume(); err != ErrPoolNotRunning {
+		t.Fatalf("Resume() after Stop(): %v, want ErrPoolNotRunning", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []PoolState{StateRunning, StatePaused, StateRunning, StateStopped}
+	if len(got) != len(want) {
+		t.Fatalf("state changes %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("state changes %v, want %v", got, want)
+		}
+	}
+}
+
+
+func TestPausedPoolRunsNothing(t *testing.T) {
+	tp := startPool(t, WithWorkers(2))
+	if err := tp.Pause(); err != nil {
+		t.Fatalf("Pause(): %v", err)
+	}
+
+	h, err := tp.SubmitWithHandle(context.Background(), valueJob(1))
+	if err != nil {
+		t.Fatalf("SubmitWithHandle(): %v", err)
+	}
+
+	select {
+		case <-h.Done():
+			t.Fatal("job ran while the worker-pool was paused")
+		case <-time.After(50 * time.Millisecond):
+	}
+
+	if err := tp.Resume(); err != nil {
+		t.Fatalf("Resume(): %v", err)
+	}
+	if v, err := wait(t, h); (err != nil) || (v != 1) {
+		t.Fatalf("job: %v, %v", v, err)
+	}
+}
+
+
+func TestRestart(t *testing.T) {
+	tp := startPool(t, WithWorkers(1))
+	tp.Stop()
+	tp.waitStart(t)
+
+	if err := tp.TrySubmit(valueJob(1)); err != ErrPoolStopped {
+		t.Fatalf("TrySubmit() after Stop(): %v, want ErrPoolStopped", err)
+	}
+
+	tp.start(t)
+	h, err := tp.SubmitWithHandle(context.Background(), valueJob(2))
+	if err != nil {
+		t.Fatalf("SubmitWithHandle() after restart: %v", err)
+	}
+	if v, err := wait(t, h); (err != nil) || (v != 2) {
+		t.Fatalf("job: %v, %v", v, err)
+	}
+}
@@ -0,0 +1,301 @@
+/* *****************************************************************************
+Copyright (c) 2023, sameeroak1110 (sameeroak1110@gmail.com)
+BSD 3-Clause License.
+
+Package     : github.com/sameeroak1110/gowp
+Filename    : github.com/sameeroak1110/gowp/stats.go
+File-type   : GoLang source code file
+
+Compiler/Runtime: go version go1.20.5 linux/amd64
+
+Version History
+Version     : 1.0
+Author      : Sameer Oak (sameeroak1110@gmail.com)
+
+Description :
+- Worker-pool statistics, Stats(). Job counts since the worker-pool was created, the current
+queue length and worker usage, throughput, and latency percentiles.
+- Counts are updated under a single lock, thus a snapshot is consistent, e.g., Completed is
+always the sum of the final states.
+- Throughput is an exponentially weighted moving average over about a minute, updated lazily in
+intervals of a second. Latency percentiles are over a window of the most recent jobs.
+***************************************************************************** */
+package gowp
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const statsInterval time.Duration = time.Second
+const statsWindow int = 1024  // no. of the most recent latencies percentiles are computed over.
+
+// weight of the latest interval in the throughput average, averages over about a minute.
+var statsAlpha float64 = 1 - math.Exp(-1.0 / 60)
+
+// LatencyStats summarises the latencies of the most recent jobs.
+type LatencyStats struct {
+	Count int            // no. of latencies summarised.
+	Mean  time.Duration
+	P50   time.Duration
+	P90   time.Duration
+	P99   time.Duration
+	Max   time.Duration
+}
+
+// PoolStats is a snapshot of the worker-pool statistics, see Stats().
+type PoolStats struct {
+	Submitted  uint64        // no. of jobs accepted, queued or scheduled.
+	Started    uint64        // no. of jobs that began running.
+	Completed  uint64        // no. of jobs finished, in any final state.
+	Succeeded  uint64        // no. of jobs finished without an error.
+	Failed     uint64        // no. of jobs finished with an error, after their retries.
+	Panicked   uint64        // no. of the failed jobs that panicked.
+	Cancelled  uint64        // no. of jobs cancelled, or not run because the worker-pool has been stopped.
+	TimedOut   uint64        // no. of jobs whose context expired before they finished.
+	Queued     int           // no. of jobs in the job queue right now.
+	Scheduled  int           // no. of jobs waiting for their due time right now.
+	Workers    int32         // no. of workers.
+	Busy       int32         // no. of workers running a job.
+	Idle       int32         // no. of workers not running a job.
+	Throughput float64       // jobs completed per second, moving average.
+	QueueWait  LatencyStats  // time jobs waited in the job queue.
+	RunTime    LatencyStats  // time jobs took to run, including their retries.
+}
+
+// latencies summed up since the worker-pool was created. the autoscaler averages the difference
+// in between two of its evaluations.
+type latencyTotals struct {
+	qwait time.Duration
+	qwaitN uint64
+	runtime time.Duration
+	runtimeN uint64
+}
+
+// window of the most recent latencies, a ring buffer.
+type latencyWindow struct {
+	samples []time.Duration
+	next int
+	n int
+}
+
+// statistics collected by the worker-pool.
+type poolStats struct {
+	mu sync.Mutex
+	counts PoolStats          // counts part of the snapshot.
+	pending uint64            // no. of jobs completed in the current interval.
+	rate float64              // throughput average, jobs per second.
+	tick time.Time            // when the current interval began.
+	qwait latencyWindow
+	runtime latencyWindow
+	totals latencyTotals
+}
+
+
+func newPoolStats() *poolStats {
+	return &poolStats {
+		tick: time.Now(),
+		qwait: latencyWindow{samples: make([]time.Duration, statsWindow)},
+		runtime: latencyWindow{samples: make([]time.Duration, statsWindow)},
+	}
+}
+
+
+func (w *latencyWindow) add(d time.Duration) {
+	w.samples[w.next] = d
+	w.next = (w.next + 1) % len(w.samples)
+	if w.n < len(w.samples) {
+		w.n++
+	}
+}
+
+
+// summarises the window. sorts the samples, thus it's invoked on a copy of the window.
+func (w *latencyWindow) summary() LatencyStats {
+	ls := LatencyStats{Count: w.n}
+	if w.n == 0 {
+		return ls
+	}
+
+	samples := w.samples[:w.n]
+	sort.Slice(samples, func(i, k int) bool {
+		return samples[i] < samples[k]
+	})
+
+	var sum time.Duration
+	for _, d := range samples {
+		sum += d
+	}
+	at := func(p float64) time.Duration {
+		return samples[int(math.Ceil(p * float64(w.n))) - 1]
+	}
+
+	ls.Mean = sum / time.Duration(w.n)
+	ls.P50 = at(0.50)
+	ls.P90 = at(0.90)
+	ls.P99 = at(0.99)
+	ls.Max = samples[w.n - 1]
+
+	return ls
+}
+
+
+// moves the throughput average on to now. mu must be held.
+func (s *poolStats) advance(now time.Time) {
+	n := int64(now.Sub(s.tick) / statsInterval)
+	if n <= 0 {
+		return
+	}
+
+	// the interval with the pending completions, followed by n-1 intervals with none.
+	s.rate += statsAlpha * (float64(s.pending) / statsInterval.Seconds() - s.rate)
+	s.rate *= math.Pow(1 - statsAlpha, float64(n - 1))
+	s.pending = 0
+	s.tick = s.tick.Add(time.Duration(n) * statsInterval)
+
+	return
+}
+
+
+func (s *poolStats) submitted() {
+	s.mu.Lock()
+	s.counts.Submitted++
+	s.mu.Unlock()
+}
+
+
+func (s *poolStats) started() {
+	s.mu.Lock()
+	s.counts.Started++
+	s.mu.Unlock()
+}
+
+
+// counts a job that's finished in the given state.
+func (s *poolStats) finished(state JobState, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.advance(time.Now())
+	s.pending++
+
+	s.counts.Completed++
+	switch state {
+		case JobSucceeded:
+			s.counts.Succeeded++
+		case JobFailed:
+			s.counts.Failed++
+			var pe *PanicError
+			if errors.As(err, &pe) {
+				s.counts.Panicked++
+			}
+		case JobCancelled:
+			s.counts.Cancelled++
+		case JobTimedOut:
+			s.counts.TimedOut++
+	}
+
+	return
+}
+
+
+// records the queue wait of a job that's just been popped.
+func (s *poolStats) observeQueueWait(d time.Duration) {
+	s.mu.Lock()
+	s.qwait.add(d)
+	s.totals.qwait += d
+	s.totals.qwaitN++
+	s.mu.Unlock()
+}
+
+
+// records the run time, including retries, of a job that's just been run.
+func (s *poolStats) observeRunTime(d time.Duration) {
+	s.mu.Lock()
+	s.runtime.add(d)
+	s.totals.runtime += d
+	s.totals.runtimeN++
+	s.mu.Unlock()
+}
+
+
+// job counts, without the rest of the snapshot. read by the terminator.
+func (s *poolStats) jobCounts() PoolStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.counts
+}
+
+
+// latencies summed up so far. read by the autoscaler.
+func (s *poolStats) latencyTotals() latencyTotals {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.totals
+}
+
+
+/* *****************************************************************************
+Description : Returns a snapshot of the worker-pool statistics.
+
+Receiver    : *WorkerPool
+
+Implements  : NA
+
+Arguments   : NA
+
+Return value:
+1> PoolStats: Snapshot of the statistics.
+
+Additional note:
+Job counts are consistent with one another. Queue length and worker usage are sampled right after
+them, thus they may be off by a job or so from the counts on a busy worker-pool.
+***************************************************************************** */
+func (pwp *WorkerPool) Stats() PoolStats {
+	s := pwp.stats
+
+	s.mu.Lock()
+	s.advance(time.Now())
+	ps := s.counts
+	ps.Throughput = s.rate
+	// windows are copied, so that sorting them doesn't hold the lock.
+	qwait := s.qwait
+	qwait.samples = append([]time.Duration(nil), s.qwait.samples[:s.qwait.n]...)
+	runtime := s.runtime
+	runtime.samples = append([]time.Duration(nil), s.runtime.samples[:s.runtime.n]...)
+	s.mu.Unlock()
+
+	ps.QueueWait = qwait.summary()
+	ps.RunTime = runtime.summary()
+
+	pwp.qmu.Lock()
+	ps.Queued = pwp.jobq.len()
+	pwp.qmu.Unlock()
+
+	pwp.smu.Lock()
+	ps.Scheduled = len(pwp.sched)
+	pwp.smu.Unlock()
+
+	ps.Workers, ps.Busy, ps.Idle = pwp.workerUsage()
+
+	return ps
+}
+
+
+// no. of workers, of those running a job, and of the rest. a worker held by the dispatcher while
+// it waits for a job is idle.
+func (pwp *WorkerPool) workerUsage() (workers, busy, idle int32) {
+	workers = pwp.GetSize()
+	busy = atomic.LoadInt32(&pwp.busycnt)
+	if busy > workers {
+		busy = workers  // the worker-pool has just shrunk, the retiring workers are still busy.
+	}
+
+	return workers, busy, workers - busy
+}
@@ -0,0 +1,99 @@
+package gowp
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLatencySummary(t *testing.T) {
+	ms := func(ns ...int) []time.Duration {
+		var ds []time.Duration
+		for _, n := range ns {
+			ds = append(ds, time.Duration(n) * time.Millisecond)
+		}
+		return ds
+	}
+	seq := func(n int) []time.Duration {
+		var ds []time.Duration
+		for i := n; i > 0; i-- {
+			ds = append(ds, time.Duration(i) * time.Millisecond)
+		}
+		return ds
+	}
+
+	tests := []struct {
+		name string
+		size int
+		samples []time.Duration
+		want LatencyStats
+	}{
+		{"empty", 4, nil, LatencyStats{}},
+		{"one", 4, ms(7), LatencyStats{1, 7 * time.Millisecond, 7 * time.Millisecond, 7 * time.Millisecond, 7 * time.Millisecond, 7 * time.Millisecond}},
+		{"unsorted", 8, ms(4, 1, 3, 2), LatencyStats{4, 2500 * time.Microsecond, 2 * time.Millisecond, 4 * time.Millisecond, 4 * time.Millisecond, 4 * time.Millisecond}},
+		{"hundred", 100, seq(100), LatencyStats{100, 50500 * time.Microsecond, 50 * time.Millisecond, 90 * time.Millisecond, 99 * time.Millisecond, 100 * time.Millisecond}},
+		{"window wraps", 3, ms(100, 100, 1, 2, 3), LatencyStats{3, 2 * time.Millisecond, 2 * time.Millisecond, 3 * time.Millisecond, 3 * time.Millisecond, 3 * time.Millisecond}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := latencyWindow{samples: make([]time.Duration, tt.size)}
+			for _, d := range tt.samples {
+				w.add(d)
+			}
+			if got := w.summary(); got != tt.want {
+				t.Fatalf("summary() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+
+func TestStatsCounts(t *testing.T) {
+	tp := startPool(t, WithWorkers(2), WithJobTimeout(20 * time.Millisecond))
+
+	jobs := []JobProcessor{
+		valueJob(1),
+		valueJob(2),
+		errJob(errTest),
+		Func("panic", func(context.Context) (interface{}, error) { panic("boom") }),
+		blockJob(nil, nil),  // times out.
+	}
+	for _, job := range jobs {
+		h, err := tp.SubmitWithHandle(context.Background(), job)
+		if err != nil {
+			t.Fatalf("SubmitWithHandle(): %v", err)
+		}
+		wait(t, h)
+	}
+
+	// cancelled before it runs.
+	tp.Pause()
+	h, err := tp.SubmitWithHandle(context.Background(), valueJob(3))
+	if err != nil {
+		t.Fatalf("SubmitWithHandle(): %v", err)
+	}
+	tp.Cancel(h.GetID())
+	if _, err := wait(t, h); !errors.Is(err, ErrJobCancelled) {
+		t.Fatalf("job: %v, want ErrJobCancelled", err)
+	}
+	tp.Resume()
+
+	want := PoolStats{Submitted: 6, Started: 5, Completed: 6, Succeeded: 2, Failed: 2, Panicked: 1, Cancelled: 1, TimedOut: 1}
+	st := tp.Stats()
+	got := PoolStats{Submitted: st.Submitted, Started: st.Started, Completed: st.Completed, Succeeded: st.Succeeded,
+		Failed: st.Failed, Panicked: st.Panicked, Cancelled: st.Cancelled, TimedOut: st.TimedOut}
+	if got != want {
+		t.Fatalf("Stats() counts %+v, want %+v", got, want)
+	}
+	if n := tp.GetTimeoutCnt(); n != st.TimedOut {
+		t.Fatalf("GetTimeoutCnt() = %d, want Stats().TimedOut %d", n, st.TimedOut)
+	}
+	if (st.RunTime.Count != 5) || (st.QueueWait.Count != 5) {
+		t.Fatalf("%d run times, %d queue waits, want 5 each", st.RunTime.Count, st.QueueWait.Count)
+	}
+	if (st.Workers != 2) || (st.Busy != 0) || (st.Idle != 2) {
+		t.Fatalf("workers %d, busy %d, idle %d, want 2, 0, 2", st.Workers, st.Busy, st.Idle)
+	}
+}
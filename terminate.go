@@ -0,0 +1,207 @@
+/* *****************************************************************************
+Copyright (c) 2023, sameeroak1110 (sameeroak1110@gmail.com)
+BSD 3-Clause License.
+
+Package     : github.com/sameeroak1110/gowp
+Filename    : github.com/sameeroak1110/gowp/terminate.go
+File-type   : GoLang source code file
+
+Compiler/Runtime: go version go1.20.5 linux/amd64
+
+Version History
+Version     : 1.0
+Author      : Sameer Oak (sameeroak1110@gmail.com)
+
+Description :
+- Termination policies. The worker-pool evaluates them while it's in action, and shuts itself
+down gracefully, as through Shutdown(), once any of them holds.
+- Policies are evaluated as each job finishes and periodically, so that the duration and the
+idle based ones hold even if no job finishes.
+- Counts and durations are as of the current run of Start().
+***************************************************************************** */
+package gowp
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+const terminationInterval time.Duration = 100 * time.Millisecond
+
+// What a TerminationPolicy decides upon.
+type TerminationInfo struct {
+	Completed uint64         // no. of jobs finished, in any final state.
+	Succeeded uint64         // no. of jobs finished without an error.
+	Failed    uint64         // no. of jobs failed, with an error or a panic, after their retries.
+	Running   time.Duration  // how long the worker-pool has been in action.
+	IdleFor   time.Duration  // how long the job queue has been empty with no job running.
+	Queued    int            // no. of queued jobs.
+	Busy      int32          // no. of jobs running.
+	stats     PoolStats      // Stats() snapshot, taken only if a When() policy is evaluated.
+}
+
+// TerminationPolicy returns true once the worker-pool should shut down.
+type TerminationPolicy interface {
+	ShouldTerminate(TerminationInfo) bool
+}
+
+// TerminationPolicyFunc adapts a function to TerminationPolicy.
+type TerminationPolicyFunc func(TerminationInfo) bool
+
+
+func (f TerminationPolicyFunc) ShouldTerminate(ti TerminationInfo) bool {
+	return f(ti)
+}
+
+
+// AfterCompleted holds once n jobs have finished, successfully or not.
+func AfterCompleted(n uint64) TerminationPolicy {
+	return TerminationPolicyFunc(func(ti TerminationInfo) bool {
+		return ti.Completed >= n
+	})
+}
+
+
+// AfterFailures holds once n jobs have failed. Cancelled and timed out jobs aren't counted, as
+// with Stats().Failed.
+func AfterFailures(n uint64) TerminationPolicy {
+	return TerminationPolicyFunc(func(ti TerminationInfo) bool {
+		return ti.Failed >= n
+	})
+}
+
+
+// AfterDuration holds once the worker-pool has been in action for d.
+func AfterDuration(d time.Duration) TerminationPolicy {
+	return TerminationPolicyFunc(func(ti TerminationInfo) bool {
+		return ti.Running >= d
+	})
+}
+
+
+// AfterIdle holds once the job queue has been empty, with no job running, for d.
+func AfterIdle(d time.Duration) TerminationPolicy {
+	return TerminationPolicyFunc(func(ti TerminationInfo) bool {
+		return (ti.IdleFor > 0) && (ti.IdleFor >= d)
+	})
+}
+
+
+// termination policy of When().
+type whenPolicy func(PoolStats) bool
+
+
+func (f whenPolicy) ShouldTerminate(ti TerminationInfo) bool {
+	return f(ti.stats)
+}
+
+
+// When holds once pred does. pred is passed a Stats() snapshot, thus its counts are since the
+// worker-pool was created rather than as of the current run of Start().
+func When(pred func(PoolStats) bool) TerminationPolicy {
+	return whenPolicy(pred)
+}
+
+
+// WithTermination adds termination policies. The worker-pool shuts down once any of them holds.
+func WithTermination(policies ...TerminationPolicy) Option {
+	return func(cfg *poolConfig) error {
+		for _, tp := range policies {
+			if tp == nil {
+				return fmt.Errorf("%w: nil termination policy", ErrInvalidOption)
+			}
+		}
+		cfg.opts.Terminate = append(cfg.opts.Terminate, policies...)
+		return nil
+	}
+}
+
+
+// wakes up the terminator once a job finishes. must not block.
+func (pwp *WorkerPool) notifyTerminator() {
+	select {
+		case pwp.tnotify <- struct{}{}:
+		default:
+	}
+
+	return
+}
+
+
+/* *****************************************************************************
+Description : Evaluates the termination policies. Invoked as a go-routine by Start() if there are
+any policies.
+
+Receiver    : *WorkerPool
+
+Implements  : NA
+
+Arguments   :
+1> ctx context.Context: Context passed to Start().
+2> stopped <-chan struct{}: Closed once the current run of Start() returns.
+3> started time.Time: When the current run of Start() began.
+4> base PoolStats: Job counts of the worker-pool statistics as of the current run began.
+
+Return value: NA
+
+Additional note:
+Once a policy holds, the worker-pool is drained and the terminator returns.
+***************************************************************************** */
+func (pwp *WorkerPool) runTerminator(ctx context.Context, stopped <-chan struct{}, started time.Time, base PoolStats) {
+	ticker := time.NewTicker(terminationInterval)
+	defer ticker.Stop()
+
+	var idleSince time.Time
+	for {
+		select {
+			case <-ctx.Done():
+				return
+
+			case <-pwp.GetContext().Done():
+				return
+
+			case <-stopped:
+				return
+
+			case <-ticker.C:
+			case <-pwp.tnotify:
+		}
+
+		// counts are those of the worker-pool statistics, as of the current run.
+		ps := pwp.stats.jobCounts()
+		ti := TerminationInfo {
+			Completed: ps.Completed - base.Completed,
+			Succeeded: ps.Succeeded - base.Succeeded,
+			Failed: ps.Failed - base.Failed,
+			Running: time.Since(started),
+			Busy: atomic.LoadInt32(&pwp.busycnt),
+		}
+
+		pwp.qmu.Lock()
+		ti.Queued = pwp.jobq.len()
+		pwp.qmu.Unlock()
+
+		if (ti.Queued == 0) && (ti.Busy == 0) {
+			if idleSince.IsZero() {
+				idleSince = time.Now()
+			}
+			ti.IdleFor = time.Since(idleSince)
+		} else {
+			idleSince = time.Time{}
+		}
+
+		taken := false
+		for _, p := range pwp.terminate {
+			if _, ok := p.(whenPolicy); ok && !taken {
+				ti.stats = pwp.Stats()
+				taken = true
+			}
+			if p.ShouldTerminate(ti) {
+				pwp.requestStop()
+				return
+			}
+		}
+	}
+}
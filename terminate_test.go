@@ -0,0 +1,77 @@
+package gowp
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTermination(t *testing.T) {
+	timeout := Func("timeout", func(ctx context.Context) (interface{}, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+
+	tests := []struct {
+		name string
+		policy TerminationPolicy
+		before []JobProcessor  // jobs after which the worker-pool is still running.
+		last JobProcessor      // job after which the worker-pool shuts down, nil if none is needed.
+	}{
+		{"after completed", AfterCompleted(3), []JobProcessor{valueJob(1), errJob(errTest)}, valueJob(3)},
+		{"failures aren't timeouts", AfterFailures(1), []JobProcessor{timeout, valueJob(1)}, errJob(errTest)},
+		{"panics are failures", AfterFailures(2), []JobProcessor{errJob(errTest)}, Func("panic", func(context.Context) (interface{}, error) { panic("boom") })},
+		{"after duration", AfterDuration(50 * time.Millisecond), nil, nil},
+		{"after idle", AfterIdle(50 * time.Millisecond), nil, nil},
+		{"when", When(func(ps PoolStats) bool { return ps.TimedOut >= 2 }), []JobProcessor{timeout, errJob(errTest)}, timeout},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tp := startPool(t, WithWorkers(1), WithJobTimeout(10 * time.Millisecond), WithTermination(tt.policy))
+
+			submit := func(job JobProcessor) {
+				t.Helper()
+				h, err := tp.SubmitWithHandle(context.Background(), job)
+				if err != nil {
+					t.Fatalf("SubmitWithHandle(): %v", err)
+				}
+				wait(t, h)
+			}
+			for _, job := range tt.before {
+				submit(job)
+			}
+			if tt.last != nil {
+				time.Sleep(2 * terminationInterval)
+				if s := tp.State(); s != StateRunning {
+					t.Fatalf("worker-pool %s before the last job, want it running", s)
+				}
+				submit(tt.last)
+			}
+
+			tp.waitStart(t)
+			if s := tp.State(); s != StateStopped {
+				t.Fatalf("worker-pool %s, want it stopped", s)
+			}
+		})
+	}
+}
+
+
+func TestTerminationCancelledNotFailed(t *testing.T) {
+	tp := startPool(t, WithWorkers(1), WithTermination(AfterFailures(1)))
+
+	started := make(chan struct{})
+	h, err := tp.SubmitWithHandle(context.Background(), blockJob(started, nil))
+	if err != nil {
+		t.Fatalf("SubmitWithHandle(): %v", err)
+	}
+	<-started
+	tp.Cancel(h.GetID())
+	wait(t, h)
+
+	time.Sleep(2 * terminationInterval)
+	if s := tp.State(); s != StateRunning {
+		t.Fatalf("worker-pool %s after a cancelled job, want it running", s)
+	}
+}
@@ -0,0 +1,81 @@
+package gowp
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// job that runs for d, with its own timeout if timeout is set.
+type timeoutJob struct {
+	d time.Duration
+	timeout time.Duration
+	ignoreCtx bool  // runs for d even if its context expires, and succeeds.
+}
+
+func (j timeoutJob) GetName() string {
+	return "timeout"
+}
+
+func (j timeoutJob) Process(ctx context.Context, _ context.CancelFunc, _ int, _ bool) (interface{}, error) {
+	if j.ignoreCtx {
+		time.Sleep(j.d)
+		return 1, nil
+	}
+
+	select {
+		case <-time.After(j.d):
+			return 1, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+	}
+}
+
+func (j timeoutJob) Timeout() time.Duration {
+	return j.timeout
+}
+
+
+func TestJobTimeout(t *testing.T) {
+	ms := time.Millisecond
+	tests := []struct {
+		name string
+		poolTimeout time.Duration
+		job timeoutJob
+		timedOut bool
+	}{
+		{"no timeout", 0, timeoutJob{d: 20 * ms}, false},
+		{"finishes in time", time.Hour, timeoutJob{d: ms}, false},
+		{"pool default", 20 * ms, timeoutJob{d: time.Hour}, true},
+		{"own timeout", 0, timeoutJob{d: time.Hour, timeout: 20 * ms}, true},
+		{"own timeout precedes the default", 10 * ms, timeoutJob{d: 50 * ms, timeout: time.Hour}, false},
+		{"ignores its context", 10 * ms, timeoutJob{d: 50 * ms, ignoreCtx: true}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tp := startPool(t, WithWorkers(1), WithJobTimeout(tt.poolTimeout))
+
+			h := mustSubmit(t, tp, tt.job)
+			v, err := wait(t, h)
+			if tt.timedOut != errors.Is(err, context.DeadlineExceeded) {
+				t.Fatalf("job: %v, %v", v, err)
+			}
+			if tt.timedOut && (v != nil) {
+				t.Fatalf("timed out job returned %v", v)
+			}
+
+			want, wantCnt := JobSucceeded, uint64(0)
+			if tt.timedOut {
+				want, wantCnt = JobTimedOut, 1
+			}
+			if ji, _ := tp.JobInfo(h.GetID()); ji.State != want {
+				t.Fatalf("job %s, want %s", ji.State, want)
+			}
+			if n := tp.GetTimeoutCnt(); n != wantCnt {
+				t.Fatalf("GetTimeoutCnt() = %d, want %d", n, wantCnt)
+			}
+		})
+	}
+}
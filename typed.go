@@ -0,0 +1,166 @@
+/* *****************************************************************************
+Copyright (c) 2023, sameeroak1110 (sameeroak1110@gmail.com)
+BSD 3-Clause License.
+
+Package     : github.com/sameeroak1110/gowp
+Filename    : github.com/sameeroak1110/gowp/typed.go
+File-type   : GoLang source code file
+
+Compiler/Runtime: go version go1.20.5 linux/amd64
+
+Version History
+Version     : 1.0
+Author      : Sameer Oak (sameeroak1110@gmail.com)
+
+Description :
+- Function based jobs. Func() adapts a plain function to a job, so a named type isn't needed.
+- TypedPool[In, Out], a worker-pool whose jobs are the inputs of a single function. Results come
+back as Out rather than interface{}. It's a WorkerPool underneath, and all of its methods are
+available. Only Submit() and TrySubmit() take an input, rather than a job, and return a TypedHandle.
+***************************************************************************** */
+package gowp
+
+import (
+	"context"
+	"fmt"
+)
+
+// JobFunc is a job as a function.
+type JobFunc func(context.Context) (interface{}, error)
+
+// adapts a JobFunc to JobProcessorV2.
+type funcJob struct {
+	name string
+	f JobFunc
+}
+
+// job of a TypedPool, one input of its function.
+type typedJob[In, Out any] struct {
+	name string
+	fn func(context.Context, In) (Out, error)
+	in In
+}
+
+// TypedPool is a worker-pool running fn on each submitted input.
+type TypedPool[In, Out any] struct {
+	*WorkerPool
+	fn func(context.Context, In) (Out, error)
+}
+
+// TypedHandle is a future of an input submitted to a TypedPool.
+type TypedHandle[Out any] struct {
+	h *JobHandle
+}
+
+
+// Func returns a job, named name, that runs f.
+func Func(name string, f JobFunc) JobProcessor {
+	return V2(funcJob{name: name, f: f})
+}
+
+
+func (j funcJob) GetName() string {
+	return j.name
+}
+
+
+func (j funcJob) Process(ctx context.Context, _ JobControl) (interface{}, error) {
+	return j.f(ctx)
+}
+
+
+func (j typedJob[In, Out]) GetName() string {
+	return j.name
+}
+
+
+func (j typedJob[In, Out]) Process(ctx context.Context, _ JobControl) (interface{}, error) {
+	return j.fn(ctx, j.in)
+}
+
+
+/* *****************************************************************************
+Description : Creates a typed worker-pool.
+
+Receiver    : NA
+
+Implements  : NA
+
+Arguments   :
+1> ctx context.Context: Same as New().
+2> fn func(context.Context, In) (Out, error): Function run on each submitted input.
+3> opts ...Option: Worker-pool options, same as New().
+
+Return value:
+1> *TypedPool[In, Out]: Reference to the newly created typed worker-pool.
+2> error: Same as New(). ErrInvalidOption if fn is nil.
+
+Additional note:
+The typed worker-pool is started, stopped, and shut down like a WorkerPool.
+***************************************************************************** */
+func NewTypedPool[In, Out any](ctx context.Context, fn func(context.Context, In) (Out, error), opts ...Option) (*TypedPool[In, Out], error) {
+	if fn == nil {
+		return nil, fmt.Errorf("%w: nil typed worker-pool function", ErrInvalidOption)
+	}
+
+	pwp, err := New(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TypedPool[In, Out]{WorkerPool: pwp, fn: fn}, nil
+}
+
+
+func (tp *TypedPool[In, Out]) job(in In) JobProcessor {
+	return V2(typedJob[In, Out]{name: tp.GetName(), fn: tp.fn, in: in})
+}
+
+
+// Submit adds an input to the worker-pool, same as WorkerPool.Submit().
+func (tp *TypedPool[In, Out]) Submit(ctx context.Context, in In, opts ...SubmitOption) (*TypedHandle[Out], error) {
+	h, err := tp.WorkerPool.SubmitWithHandle(ctx, tp.job(in), opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TypedHandle[Out]{h: h}, nil
+}
+
+
+// TrySubmit adds an input to the worker-pool only if there's room in the job queue right away,
+// same as WorkerPool.TrySubmit().
+func (tp *TypedPool[In, Out]) TrySubmit(in In, opts ...SubmitOption) (*TypedHandle[Out], error) {
+	j := tp.newJob(tp.job(in), opts...)
+	if err := tp.enqueue(context.Background(), j, false); err != nil {
+		return nil, err
+	}
+
+	return &TypedHandle[Out]{h: j.handle}, nil
+}
+
+
+// Handle returns the underlying JobHandle.
+func (th *TypedHandle[Out]) Handle() *JobHandle {
+	return th.h
+}
+
+
+func (th *TypedHandle[Out]) GetID() uint64 {
+	return th.h.GetID()
+}
+
+
+// Done returns a channel that's closed once the output is available.
+func (th *TypedHandle[Out]) Done() <-chan struct{} {
+	return th.h.Done()
+}
+
+
+// Wait waits for the output, same as JobHandle.Wait(). Output is the zero value if there isn't one.
+func (th *TypedHandle[Out]) Wait(ctx context.Context) (Out, error) {
+	v, err := th.h.Wait(ctx)
+	out, _ := v.(Out)
+
+	return out, err
+}
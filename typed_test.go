@@ -0,0 +1,98 @@
+package gowp
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+)
+
+// creates a typed worker-pool that formats non-negative ints, and starts it. it's stopped once
+// the test ends.
+func startTypedPool(t *testing.T, opts ...Option) *TypedPool[int, string] {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	typed, err := NewTypedPool(ctx, func(_ context.Context, in int) (string, error) {
+		if in < 0 {
+			return "", errTest
+		}
+		return strconv.Itoa(in), nil
+	}, opts...)
+	if err != nil {
+		cancel()
+		t.Fatalf("NewTypedPool(): %v", err)
+	}
+
+	tp := &testPool{WorkerPool: typed.WorkerPool, cancel: cancel}
+	t.Cleanup(func() {
+		tp.Stop()
+		tp.cancel()
+		tp.wg.Wait()
+	})
+	tp.start(t)
+
+	return typed
+}
+
+
+func TestFunc(t *testing.T) {
+	job := Func("answer", func(context.Context) (interface{}, error) {
+		return 42, nil
+	})
+	if job.GetName() != "answer" {
+		t.Fatalf("GetName() = %q", job.GetName())
+	}
+
+	tp := startPool(t, WithWorkers(1))
+	if v, err := wait(t, mustSubmit(t, tp, job)); (err != nil) || (v != 42) {
+		t.Fatalf("job: %v, %v", v, err)
+	}
+}
+
+
+func TestTypedPool(t *testing.T) {
+	tests := []struct {
+		name string
+		in int
+		try bool  // submitted through TrySubmit().
+		want string
+		wantErr error
+	}{
+		{"output", 7, false, "7", nil},
+		{"error", -1, false, "", errTest},
+		{"try submit", 12, true, "12", nil},
+	}
+
+	typed := startTypedPool(t, WithWorkers(2))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var th *TypedHandle[string]
+			var err error
+			if tt.try {
+				th, err = typed.TrySubmit(tt.in)
+			} else {
+				th, err = typed.Submit(context.Background(), tt.in)
+			}
+			if err != nil {
+				t.Fatalf("submit: %v", err)
+			}
+
+			if _, err := wait(t, th.Handle()); (th.GetID() != th.Handle().GetID()) || !errors.Is(err, tt.wantErr) {
+				t.Fatalf("job %d: %v", th.GetID(), err)
+			}
+			<-th.Done()
+			out, err := th.Wait(context.Background())
+			if (out != tt.want) || !errors.Is(err, tt.wantErr) || ((err == nil) != (tt.wantErr == nil)) {
+				t.Fatalf("Wait(): %q, %v, want %q, %v", out, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+
+func TestTypedPoolInvalid(t *testing.T) {
+	if _, err := NewTypedPool[int, int](context.Background(), nil); !errors.Is(err, ErrInvalidOption) {
+		t.Fatalf("NewTypedPool(nil): %v, want ErrInvalidOption", err)
+	}
+}
@@ -3,6 +3,7 @@ package gowp
 import (
 	"context"
 	"sync"
+	"time"
 )
 
 
@@ -10,6 +11,14 @@ type Job struct {
 	id uint64         // generated internally using atomic.AddUint64().
 	name string       // job name, optional.
 	data JobProcessor // data part, any type that implements JobProcessor.
+	handle *JobHandle // handle through which the submitter awaits the job execution status.
+	submittedAt time.Time // time the job was submitted at.
+	priority int      // higher the priority, sooner the job is popped from a priority queue.
+	tenant string     // tenant the job is queued for in a fair queue.
+	key string        // jobs of the same key run one at a time, in order. empty means no key.
+	metadata map[string]string  // read only once the job is created.
+	ctx context.Context  // optional, the job is cancelled along with it. nil means none.
+	rec *jobRecord       // shared by all the copies of the job.
 }
 
 // - a workerpool has ID, UUID, and a name.
@@ -22,9 +31,41 @@ type WorkerPool struct {
 	id int32                      // generated internally using atomic.AddInt32().
 	uuid string                   // generated internally.
 	name string                   // user defined name of worker-pool.
-	jobq chan Job                 // jobs that workers are going to work on.
-	jobcnt uint64                 // total no. of jobs served by this wp. updated using atomic.AddUint64().
-	workers chan int32            // limited number of workers that are going to work on jobs.
+	jobq jobQueue                 // jobs that workers are going to work on. guarded by qmu.
+	qcap int                      // capacity of jobq. 0 means unbounded.
+	qmu sync.Mutex                // guards jobq, qspace, qwaiters, and qclosed.
+	qnotify chan struct{}         // wakes up Start() once a job is pushed to jobq.
+	qspace chan struct{}          // closed, and replaced, to wake up producers once there's room in jobq.
+	qwaiters int                  // no. of producers waiting for room in jobq.
+	qclosed bool                  // true once the worker-pool has been stopped, no more jobs are accepted.
+	quit chan struct{}            // closed by Stop() to end the current run of Start(). guarded by singletonCtrl.
+	stopped chan struct{}         // closed once the current run of Start() returns. guarded by singletonCtrl.
+	resumed chan struct{}         // closed unless the worker-pool is paused. guarded by singletonCtrl.
+	halted chan struct{}          // closed once the worker-pool is stopped, replaced once it's started again. guarded by singletonCtrl.
+	kmu sync.Mutex                // guards kboxes.
+	kboxes map[string]*fifoQueue  // mailboxes of the keys having a running job, hold the parked jobs of the key.
+	smu sync.Mutex                // guards sched and sbyID.
+	sched scheduleHeap            // jobs waiting for their due time.
+	sbyID map[uint64]*scheduledJob  // scheduled jobs by job ID.
+	swake chan struct{}           // wakes up the scheduler once a job is scheduled.
+	sonce sync.Once               // starts the scheduler along with the first scheduled job.
+	lmu sync.Mutex                // guards live.
+	live map[uint64]Job           // accepted jobs whose handles aren't resolved yet, by job ID.
+	history *jobHistory           // finished jobs. nil if none are kept.
+	stats *poolStats              // job counts, throughput, and latencies, see Stats().
+	cmu sync.Mutex                // guards crons, cnextID, and the cron entries.
+	crons map[CronID]*cronEntry   // cron entries by ID.
+	cnextID CronID                // last cron entry ID handed out.
+	cwake chan struct{}           // wakes up the cron go-routine once an entry is added.
+	conce sync.Once               // starts the cron go-routine along with the first entry.
+	jobcnt uint64                 // last job ID handed out. updated using atomic.AddUint64(). see Stats() for the job counts.
+	workers chan int32            // limited number of workers that are going to work on jobs. replaced by a larger one on Resize(). guarded by wmu.
+	wmu sync.Mutex                // guards workers, wsize, wnextID, and wretire.
+	wchange chan struct{}         // wakes up Start() once workers channel is replaced.
+	wsize int32                   // no. of workers, denotes worker-pool size.
+	wnextID int32                 // last worker ID handed out.
+	wretire int32                 // no. of busy workers to be retired as they finish, after the worker-pool has shrunk.
+	autoscale *AutoscaleOptions   // optional, autoscaler configuration.
 	wcnt int32                    // no. of workers in action at any given instance in time. updated using atomic.AddInt32().
 	avlwcnt int32                 // available workers at any given instance in time. updated using atomic.AddInt32().
 	startMsg string               // optional worker-pool start message.
@@ -32,15 +73,30 @@ type WorkerPool struct {
 	ctx context.Context           // passed on through upstream.
 	cancelFunc context.CancelFunc // cancel function of context. passed on through upstream.
 	wg sync.WaitGroup             // concurrency control, used in conjunction with ctx.
-	singletonCtrl *sync.Mutex     // ensures worker-pool is started/stopped only once while it's in action.
-	startFlag bool
-	stopFlag bool
+	singletonCtrl *sync.Mutex     // guards life-cycle state. ensures worker-pool is started/stopped only once while it's in action.
+	state PoolState               // life-cycle state of the worker-pool. guarded by singletonCtrl.
+	onStateChange func(from, to PoolState) // optional, invoked on each life-cycle state change.
 	isResponse bool               // true if upstream needs job execution status.
-	jobctrl bool                  // context-timeout in exec function.
+	resultq chan JobStatus        // execution status of each job is published here if isResponse is true.
+	rjobq chan resultJob          // results awaiting the result processing stage.
+	rworkers chan int32           // limited number of workers of the result processing stage.
+	rwg sync.WaitGroup            // concurrency control of the result processing stage.
+	onResultError func(Job, error) // optional, invoked if ProcessResult() returns an error.
+	onDeadLetterError func(Job, error) // optional, invoked if the dead-letter sink fails to record a job.
+	onPanic func(*PanicError)     // optional, invoked if a job panics.
+	onProgress func(Progress)     // optional, invoked when a job reports progress.
+	stopRequested int32           // 1 while a stop requested by a job is in progress. updated atomically.
+	jobTimeout time.Duration      // default timeout of each job, context passed to Process() expires after it. 0 means no timeout.
+	retryPolicy *RetryPolicy      // default retry policy of each job. nil means no retry.
+	dlsink DeadLetterSink         // optional, permanently failed jobs are recorded here.
+	dldecoder DeadLetterDecoder   // optional, decodes dead letters that aren't in memory anymore.
 
 	// worker-pool cancellation:
 	maxJobCnt       int    // maximum of jobs worker-pool has executed before cancellation. Process() method of JobProcessor{} interface uses this count.
 	shouldTerminate bool   // if true, Process() method of JobProcessor{} interface invokes cancel function to terminate the worker-pool.
+	terminate []TerminationPolicy // worker-pool shuts down once any of these holds.
+	busycnt int32          // no. of jobs running. updated atomically.
+	tnotify chan struct{}  // wakes up the terminator once a job finishes.
 }
 
 type WorkerPoolOptions struct {
@@ -48,10 +104,52 @@ type WorkerPoolOptions struct {
 	                       // if current jobcnt reaches MaxJobCnt, Process() may invoke cancellation if ShouldTerminate flag is set to true.
 						   // default value is 0 to indicate cancellation is ignored.
 	ShouldTerminate bool   // if true, Process() method of JobProcessor{} interface invokes cancel function to terminate the worker-pool.
+	                       // the worker-pool itself shuts down gracefully once MaxJobCnt jobs have finished, see AfterCompleted().
+	Terminate       []TerminationPolicy // optional, the worker-pool shuts down gracefully once any of these holds.
+	IsResponse      bool   // if true, execution status of each job is published on the channel returned by Results().
+	ResultQSize     int32  // size of results channel, at most 65536. default is same as size of the job-queue, up to 65536.
+	ResultWorkers   int32  // no. of workers of the result processing stage. default is same as worker-pool size.
+	OnResultError   func(Job, error) // optional, invoked if ProcessResult() of a JobResultProcessor returns an error.
+	OnStateChange   func(from, to PoolState) // optional, invoked on each life-cycle state change of the worker-pool.
+	OnPanic         func(*PanicError) // optional, invoked if Process() or ProcessResult() of a job panics.
+	OnProgress      func(Progress)    // optional, invoked on the job's go-routine when a JobProcessorV2 reports progress.
+	JobTimeout      time.Duration // default timeout of each job. a job may have its own through Timeouter. default is no timeout.
+	RetryPolicy     *RetryPolicy  // default retry policy of each job. a job may have its own through Retryer. default is no retry.
+	DeadLetterSink  DeadLetterSink // optional, jobs that fail permanently, including panicking ones, are recorded here.
+	DeadLetterDecoder DeadLetterDecoder // optional, decodes a dead letter that's no more in memory back into a job to requeue it.
+	OnDeadLetterError func(Job, error) // optional, invoked if DeadLetterSink.Put() fails. the failed job is dropped otherwise.
+	Autoscale       *AutoscaleOptions // optional, if set the no. of workers is scaled in between its minimum and maximum.
+	JobHistory      int    // no. of finished jobs kept for JobInfo() and Jobs(). default is 1024, negative keeps none.
+	JobHistoryMaxAge time.Duration // finished jobs older than this aren't reported. default is no limit.
 }
 
 // Status of execution of each job.
 type JobStatus struct {
-	data interface{}
-	err error
+	id uint64         // ID of the job this status belongs to.
+	name string       // name of the job this status belongs to.
+	data interface{}  // value returned by Process() method.
+	err error         // error returned by Process() method.
+	attempts int      // no. of times Process() method has been invoked.
+	rdata interface{} // value returned by ProcessResult() method if the result is a JobResultProcessor.
+	rerr error        // error returned by ProcessResult() method if the result is a JobResultProcessor.
+}
+
+// a job result that awaits the result processing stage.
+type resultJob struct {
+	job Job
+	js JobStatus
+	rp JobResultProcessor
+}
+
+// - JobHandle is a future of a submitted job.
+// - It's resolved once the job has been executed, whether successfully or not.
+// - The submitter may await the execution status through Wait() or Done().
+type JobHandle struct {
+	id uint64             // ID of the job.
+	name string           // name of the job.
+	done chan struct{}    // closed when the job execution status is available.
+	once sync.Once        // ensures the handle is resolved only once.
+	status JobStatus      // job execution status, valid once done is closed.
+	pmu sync.Mutex        // guards progress.
+	progress *Progress    // latest progress reported by the job, nil if none.
 }
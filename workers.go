@@ -0,0 +1,196 @@
+/* *****************************************************************************
+Copyright (c) 2023, sameeroak1110 (sameeroak1110@gmail.com)
+BSD 3-Clause License.
+
+Package     : github.com/sameeroak1110/gowp
+Filename    : github.com/sameeroak1110/gowp/workers.go
+File-type   : GoLang source code file
+
+Compiler/Runtime: go version go1.20.5 linux/amd64
+
+Version History
+Version     : 1.0
+Author      : Sameer Oak (sameeroak1110@gmail.com)
+
+Description :
+- Workers of the worker-pool.
+- Available workers are the worker IDs in the workers channel. Start() receives a worker from
+the channel for each job and exec() hands it back once the job is done.
+- The no. of workers can be changed while the worker-pool is in action. Growing adds new worker
+IDs to the channel, replacing the channel with a larger one if needed. Shrinking takes away
+the available workers and retires the busy ones as they finish their jobs.
+***************************************************************************** */
+package gowp
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+)
+
+var ErrInvalidSize = errors.New("gowp: invalid worker-pool size")
+
+
+// initializes wsize workers. invoked while creating the worker-pool.
+func (pwp *WorkerPool) initWorkers(wsize int32) {
+	pwp.workers = make(chan int32, wsize)
+	pwp.wchange = make(chan struct{}, 1)
+	for i := int32(1); i <= wsize; i++ {
+		pwp.workers <- i
+	}
+	pwp.wsize = wsize
+	pwp.wnextID = wsize
+	pwp.avlwcnt = wsize
+}
+
+
+/* *****************************************************************************
+Description : Waits for an available worker.
+
+Receiver    : *WorkerPool
+
+Implements  : NA
+
+Arguments   :
+1> ctx context.Context: Bounds the wait for a worker.
+2> quit <-chan struct{}: Closed when the worker-pool is stopped, ends the wait as well.
+
+Return value:
+1> int32: ID of the worker.
+2> bool: false if the wait ended without a worker.
+
+Additional note: NA
+***************************************************************************** */
+func (pwp *WorkerPool) acquireWorker(ctx context.Context, quit <-chan struct{}) (int32, bool) {
+	for {
+		pwp.wmu.Lock()
+		workers := pwp.workers
+		pwp.wmu.Unlock()
+
+		select {
+			case wid := <-workers:
+				atomic.AddInt32(&pwp.wcnt, 1)
+				atomic.AddInt32(&pwp.avlwcnt, -1)
+				return wid, true
+
+			case <-pwp.wchange:  // workers channel has been replaced.
+
+			case <-ctx.Done():
+				return 0, false
+
+			case <-pwp.GetContext().Done():
+				return 0, false
+
+			case <-quit:
+				return 0, false
+		}
+	}
+}
+
+
+// hands the worker back once its job is done, or retires it if the worker-pool has shrunk.
+func (pwp *WorkerPool) releaseWorker(wid int32) {
+	pwp.wmu.Lock()
+	defer pwp.wmu.Unlock()
+
+	atomic.AddInt32(&pwp.wcnt, -1)
+	if pwp.wretire > 0 {
+		pwp.wretire--
+		return
+	}
+
+	pwp.workers <- wid  // one more worker is made available. never blocks, channel has room for all.
+	atomic.AddInt32(&pwp.avlwcnt, 1)
+}
+
+
+/* *****************************************************************************
+Description : Changes the no. of workers of the worker-pool.
+
+Receiver    : *WorkerPool
+
+Implements  : NA
+
+Arguments   :
+1> n int32: New no. of workers.
+
+Return value:
+1> error: ErrInvalidSize if n is less than 1.
+
+Additional note:
+- May be invoked while the worker-pool is in action. Running jobs are never dropped.
+- Growing makes the new workers available right away.
+- Shrinking retires the available workers right away, and the busy ones as they finish their jobs.
+Thus, the no. of busy workers may stay above n for a while.
+***************************************************************************** */
+func (pwp *WorkerPool) Resize(n int32) error {
+	if n < 1 {
+		return ErrInvalidSize
+	}
+
+	pwp.wmu.Lock()
+	defer pwp.wmu.Unlock()
+
+	if n > pwp.wsize {
+		k := n - pwp.wsize
+
+		// workers yet to be retired are retained rather than adding new ones.
+		if pwp.wretire > 0 {
+			r := pwp.wretire
+			if r > k {
+				r = k
+			}
+			pwp.wretire -= r
+			k -= r
+		}
+
+		if int(n) > cap(pwp.workers) {
+			workers := make(chan int32, n)
+			for moved := false; !moved; {
+				select {
+					case wid := <-pwp.workers:
+						workers <- wid
+					default:
+						moved = true
+				}
+			}
+			pwp.workers = workers
+
+			// wakes up Start() waiting on the old channel.
+			select {
+				case pwp.wchange <- struct{}{}:
+				default:
+			}
+		}
+
+		for ; k > 0; k-- {
+			pwp.wnextID++
+			pwp.workers <- pwp.wnextID
+			atomic.AddInt32(&pwp.avlwcnt, 1)
+		}
+	} else if n < pwp.wsize {
+		k := pwp.wsize - n
+		for ; k > 0; k-- {
+			select {
+				case <-pwp.workers:
+					atomic.AddInt32(&pwp.avlwcnt, -1)
+					continue
+				default:
+			}
+			break
+		}
+		pwp.wretire += k  // busy workers to be retired as they finish.
+	}
+	pwp.wsize = n
+
+	return nil
+}
+
+
+// GetSize returns the no. of workers of the worker-pool.
+func (pwp *WorkerPool) GetSize() int32 {
+	pwp.wmu.Lock()
+	defer pwp.wmu.Unlock()
+
+	return pwp.wsize
+}
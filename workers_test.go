@@ -0,0 +1,89 @@
+package gowp
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestResize(t *testing.T) {
+	tests := []struct {
+		name string
+		from int32
+		to int32
+	}{
+		{"grow", 2, 5},
+		{"shrink", 5, 2},
+		{"same", 3, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tp := startPool(t, WithWorkers(tt.from))
+
+			// the first jobs block the workers, the rest record how many jobs run at once as they start.
+			var cur, peak int32
+			release := make(chan struct{})
+			blocking := int(tt.from)
+			if tt.to > tt.from {
+				blocking = int(tt.to)
+			}
+			var hs []*JobHandle
+			for i := 0; i < 10; i++ {
+				block := i < blocking
+				job := Func("counted", func(ctx context.Context) (interface{}, error) {
+					n := atomic.AddInt32(&cur, 1)
+					defer atomic.AddInt32(&cur, -1)
+					if block {
+						<-release
+						return nil, nil
+					}
+					for {
+						p := atomic.LoadInt32(&peak)
+						if (n <= p) || atomic.CompareAndSwapInt32(&peak, p, n) {
+							break
+						}
+					}
+					time.Sleep(time.Millisecond)
+					return nil, nil
+				})
+				h, err := tp.SubmitWithHandle(context.Background(), job)
+				if err != nil {
+					t.Fatalf("SubmitWithHandle(): %v", err)
+				}
+				hs = append(hs, h)
+			}
+			waitFor(t, "workers busy", func() bool {
+				return atomic.LoadInt32(&cur) == tt.from
+			})
+
+			if err := tp.Resize(tt.to); err != nil {
+				t.Fatalf("Resize(): %v", err)
+			}
+			if n := tp.GetSize(); n != tt.to {
+				t.Fatalf("GetSize() = %d, want %d", n, tt.to)
+			}
+			waitFor(t, "workers busy at the new size", func() bool {
+				return atomic.LoadInt32(&cur) == int32(blocking)
+			})
+
+			// the running jobs aren't dropped, the ones dispatched later respect the new size.
+			close(release)
+			for _, h := range hs {
+				if _, err := wait(t, h); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if p := atomic.LoadInt32(&peak); p > tt.to {
+				t.Fatalf("%d jobs ran at once, want at most %d", p, tt.to)
+			}
+		})
+	}
+
+	tp := newPool(t, WithWorkers(1))
+	if err := tp.Resize(0); !errors.Is(err, ErrInvalidSize) {
+		t.Fatalf("Resize(0): %v, want ErrInvalidSize", err)
+	}
+}